@@ -1,5 +1,10 @@
 package v1
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
 // Reference to a named object.
 type Reference struct {
 	// Name of the referenced object.
@@ -8,14 +13,39 @@ type Reference struct {
 	Namespace string `json:"namespace"`
 }
 
-// Dependency reference to the identifier of another API on which this depends
+// +kubebuilder:validation:XValidation:rule="has(self.name) || (has(self.names) && size(self.names) > 0)",message="name or names is required"
+// +kubebuilder:validation:XValidation:rule="!has(self.iterator) || has(self.name)",message="iterator requires name"
+// Dependency reference to the identifiers of other APIs on which this depends
 type Dependency struct {
 	// Name of another API on which this depends
-	Name string `json:"name"`
-	// Iterator defines a field on which iterate.
+	Name string `json:"name,omitempty"`
+	//+listType=set
+	// Names of other APIs on which this depends (together with Name)
+	Names []string `json:"names,omitempty"`
+	// Iterator defines a field (of the Name API result) on which iterate.
 	Iterator *string `json:"iterator,omitempty"`
 }
 
+// All returns the (unique) names of the APIs on which this depends, Name first.
+func (d *Dependency) All() []string {
+	if d == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(d.Names)+1)
+
+	all := make([]string, 0, len(d.Names)+1)
+	for _, el := range append([]string{d.Name}, d.Names...) {
+		if len(el) == 0 || seen[el] {
+			continue
+		}
+		seen[el] = true
+		all = append(all, el)
+	}
+
+	return all
+}
+
 // API represents a request to an HTTP service
 type API struct {
 	// Name is a (unique) identifier
@@ -31,7 +61,7 @@ type API struct {
 	Payload *string `json:"payload,omitempty"`
 	// EndpointRef a reference to an Endpoint
 	EndpointRef *Reference `json:"endpointRef,omitempty"`
-	// DependsOn reference to another API on which this depends
+	// DependsOn references to other APIs on which this depends
 	DependsOn *Dependency `json:"dependsOn,omitempty"`
 
 	Filter *string `json:"filter,omitempty"`
@@ -41,6 +71,219 @@ type API struct {
 	ErrorKey *string `json:"errorKey,omitempty"`
 
 	ExportJWT *bool `json:"exportJwt,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+
+	// MaxConcurrency is the maximum number of concurrent calls
+	// performed when iterating over a dependency.
+	MaxConcurrency *int `json:"maxConcurrency,omitempty"`
+
+	// Retry defines how failed calls are retried.
+	Retry *RetryPolicy `json:"retry,omitempty"`
+
+	// Timeout is the maximum duration of all the calls of this API (retries included).
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// When is a JQ expression evaluated against the current context before the call;
+	// the API is skipped when it yields false or null.
+	When *string `json:"when,omitempty"`
+
+	// ResponseFormat is the format of the response body; if omitted
+	// it is inferred from the response Content-Type (JSON by default).
+	ResponseFormat *ResponseFormat `json:"responseFormat,omitempty"`
+
+	// Paginate describes how to follow the next pages of the response;
+	// all the pages are concatenated before the filter is applied.
+	Paginate *Pagination `json:"paginate,omitempty"`
+
+	// RESTActionRef references another RESTAction resolved in place of the HTTP call;
+	// its (filtered) status becomes the value of this API.
+	RESTActionRef *RESTActionReference `json:"restActionRef,omitempty"`
+
+	// Kubernetes selects the Kubernetes objects to read, with the user credentials,
+	// in place of the HTTP call.
+	Kubernetes *KubernetesResource `json:"kubernetes,omitempty"`
+
+	//+listType=map
+	//+listMapKey=name
+	// Env are values read from the Secrets and ConfigMaps of the RESTAction namespace;
+	// each $(NAME) occurrence in path, headers and payload is replaced by the value.
+	Env []EnvVar `json:"env,omitempty"`
+
+	// Merge defines how the results of the API calls (i.e. of the iterator
+	// elements) are stored under the API name; if omitted the first result
+	// is stored as is and the next ones are collected into an array.
+	Merge *MergePolicy `json:"merge,omitempty"`
+
+	//+kubebuilder:validation:Schemaless
+	//+kubebuilder:pruning:PreserveUnknownFields
+
+	// Fallback is the value stored under the API name when the API fails;
+	// a string wrapped in ${ } is a JQ expression evaluated against the current context.
+	Fallback *runtime.RawExtension `json:"fallback,omitempty"`
+
+	// ErrorFilter is a JQ expression reshaping the failure status
+	// before it is stored under the errorKey.
+	ErrorFilter *string `json:"errorFilter,omitempty"`
+}
+
+// MergeStrategy is the way the API results are merged.
+// +kubebuilder:validation:Enum=array;replace;concat;deepMerge;keyed
+type MergeStrategy string
+
+const (
+	// MergeArray collects each result as an array element (always an array).
+	MergeArray MergeStrategy = "array"
+	// MergeReplace keeps the last result.
+	MergeReplace MergeStrategy = "replace"
+	// MergeConcat concatenates the results arrays (always an array).
+	MergeConcat MergeStrategy = "concat"
+	// MergeDeepMerge merges the results objects recursively; later values win.
+	MergeDeepMerge MergeStrategy = "deepMerge"
+	// MergeKeyed concatenates the results arrays, deep merging the items
+	// with the same key (always an array).
+	MergeKeyed MergeStrategy = "keyed"
+)
+
+// MergePolicy defines how the API results are merged.
+type MergePolicy struct {
+	// Strategy is the merge strategy.
+	Strategy MergeStrategy `json:"strategy"`
+	// Key is the JQ expression evaluated on each item identifying it (keyed strategy only).
+	Key *string `json:"key,omitempty"`
+}
+
+// EnvVar is a named value read from a Secret or a ConfigMap.
+type EnvVar struct {
+	// Name of the variable, referenced as $(NAME).
+	Name string `json:"name"`
+	// ValueFrom is the source of the value.
+	ValueFrom EnvVarSource `json:"valueFrom"`
+}
+
+// EnvVarSource selects the key of a Secret or of a ConfigMap
+// (exactly one of them must be set).
+type EnvVarSource struct {
+	// SecretKeyRef selects a key of a Secret.
+	SecretKeyRef *KeySelector `json:"secretKeyRef,omitempty"`
+	// ConfigMapKeyRef selects a key of a ConfigMap.
+	ConfigMapKeyRef *KeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// KeySelector selects a key of an object in the RESTAction namespace.
+type KeySelector struct {
+	// Name of the object.
+	Name string `json:"name"`
+	// Key to select.
+	Key string `json:"key"`
+}
+
+// KubernetesResource selects one or more Kubernetes objects.
+// All fields can be JQ expressions evaluated against the current context.
+type KubernetesResource struct {
+	// APIVersion is the group version of the resource (e.g. apps/v1).
+	APIVersion string `json:"apiVersion"`
+	// Resource is the resource name (plural or singular, e.g. deployments).
+	Resource string `json:"resource"`
+	// Namespace of the objects (ignored for cluster scoped resources).
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the object to get; if omitted the objects are listed.
+	Name string `json:"name,omitempty"`
+	// LabelSelector restricts the listed objects by their labels.
+	LabelSelector string `json:"labelSelector,omitempty"`
+	// FieldSelector restricts the listed objects by their fields.
+	FieldSelector string `json:"fieldSelector,omitempty"`
+}
+
+// RESTActionReference is a reference to a RESTAction to resolve as an API step.
+type RESTActionReference struct {
+	Reference `json:",inline"`
+	//+listType=atomic
+	// Extras are the parameters passed to the referenced RESTAction;
+	// values can be JQ expressions evaluated against the current context.
+	Extras []Data `json:"extras,omitempty"`
+}
+
+// PaginationType is the strategy used to request the next page.
+// +kubebuilder:validation:Enum=link;cursor;page;offset
+type PaginationType string
+
+const (
+	// PaginationLink follows the rel="next" URL of the Link response header.
+	PaginationLink PaginationType = "link"
+	// PaginationCursor sets the value returned by the Next expression as query parameter.
+	PaginationCursor PaginationType = "cursor"
+	// PaginationPage increments a page number query parameter.
+	PaginationPage PaginationType = "page"
+	// PaginationOffset increments an offset query parameter by the number of received items.
+	PaginationOffset PaginationType = "offset"
+)
+
+// Pagination describes how to request the next pages of an API response.
+type Pagination struct {
+	// Type is the pagination strategy.
+	Type PaginationType `json:"type"`
+	// Items is a JQ expression extracting the items from each page (the whole page if omitted).
+	Items *string `json:"items,omitempty"`
+	// Next is a JQ expression returning the next cursor or continue token from
+	// each page (cursor type only); pagination stops when it yields null or an empty string.
+	Next *string `json:"next,omitempty"`
+	// Param is the query parameter carrying the cursor, page number or offset
+	// (defaults to "page" and "offset" for the page and offset types).
+	Param *string `json:"param,omitempty"`
+	// Start is the first page number or offset (1 for page, 0 for offset).
+	Start *int `json:"start,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+
+	// MaxPages is the maximum number of requested pages (100 if omitted).
+	MaxPages *int `json:"maxPages,omitempty"`
+}
+
+// ResponseFormat is the format of an API response body.
+// +kubebuilder:validation:Enum=json;yaml;xml;csv;text
+type ResponseFormat string
+
+const (
+	ResponseFormatJSON ResponseFormat = "json"
+	ResponseFormatYAML ResponseFormat = "yaml"
+	ResponseFormatXML  ResponseFormat = "xml"
+	ResponseFormatCSV  ResponseFormat = "csv"
+	ResponseFormatText ResponseFormat = "text"
+)
+
+// RetryErrorClass is a class of transport errors.
+// +kubebuilder:validation:Enum=timeout;connection;dns
+type RetryErrorClass string
+
+const (
+	// RetryOnTimeout matches timed out calls.
+	RetryOnTimeout RetryErrorClass = "timeout"
+	// RetryOnConnection matches refused, reset or prematurely closed connections.
+	RetryOnConnection RetryErrorClass = "connection"
+	// RetryOnDNS matches host name resolution failures.
+	RetryOnDNS RetryErrorClass = "dns"
+)
+
+// RetryPolicy defines how many times and when a failed call is retried.
+// If neither StatusCodes nor Errors are specified, calls are retried
+// on 429, 502, 503, 504 status codes and on timeout and connection errors.
+type RetryPolicy struct {
+	//+kubebuilder:validation:Minimum=1
+
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int `json:"maxAttempts"`
+	// InitialBackoff is the delay before the first retry (500ms if omitted);
+	// it doubles at each attempt, with a random jitter.
+	InitialBackoff *metav1.Duration `json:"initialBackoff,omitempty"`
+	// MaxBackoff is the upper bound of the delay between attempts (10s if omitted).
+	MaxBackoff *metav1.Duration `json:"maxBackoff,omitempty"`
+	//+listType=atomic
+	// StatusCodes are the response status codes that trigger a retry.
+	StatusCodes []int `json:"statusCodes,omitempty"`
+	//+listType=atomic
+	// Errors are the classes of transport errors that trigger a retry.
+	Errors []RetryErrorClass `json:"errors,omitempty"`
 }
 
 // ObjectReference is a reference to a named object in a specified namespace.
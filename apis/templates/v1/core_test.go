@@ -0,0 +1,52 @@
+//go:build unit
+// +build unit
+
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDependencyAll(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{
+			name: "name",
+			in:   `{"dependsOn": {"name": "users", "iterator": ".users"}}`,
+			want: []string{"users"},
+		},
+		{
+			name: "names",
+			in:   `{"dependsOn": {"names": ["users", "projects"]}}`,
+			want: []string{"users", "projects"},
+		},
+		{
+			name: "name and names",
+			in:   `{"dependsOn": {"name": "users", "names": ["projects", "users"]}}`,
+			want: []string{"users", "projects"},
+		},
+		{
+			name: "missing",
+			in:   `{}`,
+			want: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got API
+			if err := json.Unmarshal([]byte(tc.in), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got.DependsOn.All()); diff != "" {
+				t.Errorf("dependencies mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -10,6 +10,56 @@ type RESTActionSpec struct {
 	//+listType=atomic
 	API    []*API  `json:"api,omitempty"`
 	Filter *string `json:"filter,omitempty"`
+	// Timeout is the overall deadline for resolving all the APIs.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	//+kubebuilder:validation:Type=object
+	//+kubebuilder:pruning:PreserveUnknownFields
+
+	// OutputSchema is the OpenAPI v3 schema of the resolved status
+	// (the filter result); a status not matching the schema is an error.
+	OutputSchema *runtime.RawExtension `json:"outputSchema,omitempty"`
+	// Params are the declared input parameters, read from the extras;
+	// they are validated and defaulted before resolving the APIs.
+	// +listType=map
+	// +listMapKey=name
+	Params []Param `json:"params,omitempty"`
+}
+
+// ParamType is the type of an input parameter.
+// +kubebuilder:validation:Enum=string;integer;number;boolean;array;object
+type ParamType string
+
+const (
+	ParamTypeString  ParamType = "string"
+	ParamTypeInteger ParamType = "integer"
+	ParamTypeNumber  ParamType = "number"
+	ParamTypeBoolean ParamType = "boolean"
+	ParamTypeArray   ParamType = "array"
+	ParamTypeObject  ParamType = "object"
+)
+
+// Param is an input parameter of a RESTAction.
+type Param struct {
+	// Name of the parameter (the extras key).
+	Name string `json:"name"`
+	// Type of the parameter (string if omitted).
+	Type ParamType `json:"type,omitempty"`
+	// Description of the parameter.
+	Description *string `json:"description,omitempty"`
+	// Required parameters must be set if they have no default.
+	Required bool `json:"required,omitempty"`
+
+	//+kubebuilder:validation:Schemaless
+	//+kubebuilder:pruning:PreserveUnknownFields
+
+	// Default is the value of the parameter when not set.
+	Default *runtime.RawExtension `json:"default,omitempty"`
+	// Enum are the allowed values (of string, integer, number and boolean parameters).
+	// +listType=atomic
+	Enum []string `json:"enum,omitempty"`
+	// Pattern is the regular expression matched by string parameters.
+	Pattern *string `json:"pattern,omitempty"`
 }
 
 // +kubebuilder:object:root=true
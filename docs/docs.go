@@ -73,6 +73,58 @@ const docTemplate = `{
                 }
             }
         },
+        "/batch": {
+            "post": {
+                "description": "This endpoint accepts a list of object references (i.e. widgets and RESTActions),\neach one with its own pagination and extras, and resolves them concurrently as ` + "`" + `GET /call` + "`" + ` does.\nThe Kubernetes clients are shared among all the items.\nIt returns, in the same order, the status code and the result (or the failure status) of each item.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "batch"
+                ],
+                "summary": "Resolve many objects in one request",
+                "parameters": [
+                    {
+                        "description": "Objects to resolve",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.batchin"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Results of each item",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.batchout"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    }
+                }
+            }
+        },
         "/call": {
             "get": {
                 "description": "Handle Resources",
@@ -128,6 +180,18 @@ const docTemplate = `{
                         "name": "extras",
                         "in": "query"
                     },
+                    {
+                        "type": "boolean",
+                        "description": "Include the resolution trace (RESTActions only)",
+                        "name": "trace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record or replay the RESTAction HTTP exchanges (record, replay, off); requires --fixtures-override",
+                        "name": "fixtures",
+                        "in": "query"
+                    },
                     {
                         "description": "Object",
                         "name": "data",
@@ -145,6 +209,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -168,6 +239,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/response.Status"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
                     }
                 }
             },
@@ -225,6 +302,18 @@ const docTemplate = `{
                         "name": "extras",
                         "in": "query"
                     },
+                    {
+                        "type": "boolean",
+                        "description": "Include the resolution trace (RESTActions only)",
+                        "name": "trace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record or replay the RESTAction HTTP exchanges (record, replay, off); requires --fixtures-override",
+                        "name": "fixtures",
+                        "in": "query"
+                    },
                     {
                         "description": "Object",
                         "name": "data",
@@ -242,6 +331,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -265,6 +361,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/response.Status"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
                     }
                 }
             },
@@ -322,6 +424,18 @@ const docTemplate = `{
                         "name": "extras",
                         "in": "query"
                     },
+                    {
+                        "type": "boolean",
+                        "description": "Include the resolution trace (RESTActions only)",
+                        "name": "trace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record or replay the RESTAction HTTP exchanges (record, replay, off); requires --fixtures-override",
+                        "name": "fixtures",
+                        "in": "query"
+                    },
                     {
                         "description": "Object",
                         "name": "data",
@@ -339,6 +453,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -362,6 +483,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/response.Status"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
                     }
                 }
             },
@@ -419,6 +546,18 @@ const docTemplate = `{
                         "name": "extras",
                         "in": "query"
                     },
+                    {
+                        "type": "boolean",
+                        "description": "Include the resolution trace (RESTActions only)",
+                        "name": "trace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record or replay the RESTAction HTTP exchanges (record, replay, off); requires --fixtures-override",
+                        "name": "fixtures",
+                        "in": "query"
+                    },
                     {
                         "description": "Object",
                         "name": "data",
@@ -436,6 +575,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -459,6 +605,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/response.Status"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
                     }
                 }
             },
@@ -516,6 +668,18 @@ const docTemplate = `{
                         "name": "extras",
                         "in": "query"
                     },
+                    {
+                        "type": "boolean",
+                        "description": "Include the resolution trace (RESTActions only)",
+                        "name": "trace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record or replay the RESTAction HTTP exchanges (record, replay, off); requires --fixtures-override",
+                        "name": "fixtures",
+                        "in": "query"
+                    },
                     {
                         "description": "Object",
                         "name": "data",
@@ -533,6 +697,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -556,6 +727,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/response.Status"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
                     }
                 }
             }
@@ -744,107 +921,475 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.jqin": {
-            "type": "object",
-            "properties": {
-                "data": {},
-                "query": {
-                    "type": "string"
-                }
-            }
         },
-        "handlers.names": {
-            "type": "object",
-            "properties": {
-                "plural": {
-                    "type": "string"
-                },
-                "shorts": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+        "/restactions/validate": {
+            "post": {
+                "description": "This endpoint accepts a RESTAction (JSON or YAML) and statically checks it:\nthe APIs dependency graph, all the JQ expressions and the referenced endpoint secrets (readable by the user).\nIt returns the list of diagnostics, each one with the path of the offending field.",
+                "consumes": [
+                    "application/json",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "restactions"
+                ],
+                "summary": "Validate a RESTAction",
+                "parameters": [
+                    {
+                        "description": "RESTAction to validate",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RESTAction"
+                        }
                     }
-                },
-                "singular": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.serviceInfo": {
-            "type": "object",
-            "properties": {
-                "build": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "namespace": {
-                    "type": "string"
-                }
-            }
-        },
-        "response.Status": {
-            "type": "object",
-            "properties": {
-                "apiVersion": {
-                    "type": "string"
-                },
-                "code": {
-                    "description": "Suggested HTTP return code for this status, 0 if not set.",
-                    "type": "integer"
-                },
-                "kind": {
-                    "type": "string"
-                },
-                "message": {
-                    "description": "A human-readable description of the status of this operation.",
-                    "type": "string"
-                },
-                "reason": {
-                    "description": "A machine-readable description of why this operation is in the\n\"Failure\" status. If this value is empty there\nis no information available. A Reason clarifies an HTTP status\ncode but does not override it.",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/response.StatusReason"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Validation result",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.validateout"
                         }
-                    ]
-                },
-                "status": {
-                    "description": "Status of the operation.\nOne of: \"Success\" or \"Failure\".",
-                    "type": "string"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    }
                 }
             }
         },
-        "response.StatusReason": {
-            "type": "string",
-            "enum": [
-                "",
-                "Unauthorized",
-                "Forbidden",
-                "NotFound",
-                "Conflict",
-                "Gone",
-                "Invalid",
-                "Timeout",
-                "TooManyRequests",
-                "BadRequest",
-                "MethodNotAllowed",
-                "NotAcceptable",
-                "RequestEntityTooLarge",
-                "UnsupportedMediaType",
-                "UnprocessableEntity",
-                "InternalError",
-                "ServiceUnavailable"
-            ],
-            "x-enum-varnames": [
-                "StatusReasonUnknown",
-                "StatusReasonUnauthorized",
-                "StatusReasonForbidden",
-                "StatusReasonNotFound",
-                "StatusReasonConflict",
+        "/watch": {
+            "get": {
+                "description": "Streams the changes (ADDED, MODIFIED, DELETED) of the selected objects as Server-Sent Events,\nusing the user credentials. The id of each event is the object resourceVersion:\nthe stream can be resumed from it with the ` + "`" + `Last-Event-ID` + "`" + ` header or the ` + "`" + `resourceVersion` + "`" + ` parameter.\nIdle streams receive a heartbeat comment; an ` + "`" + `ERROR` + "`" + ` event (i.e. resourceVersion too old) ends the stream.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Watch Endpoint",
+                "operationId": "watch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resource API Group and Version",
+                        "name": "apiVersion",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource Plural",
+                        "name": "resource",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource namespace (all namespaces if omitted)",
+                        "name": "namespace",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource name (all objects if omitted)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Label selector restricting the watched objects",
+                        "name": "labelSelector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource version from which the changes are streamed",
+                        "name": "resourceVersion",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the last received event (overrides resourceVersion)",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of events",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    }
+                }
+            }
+        },
+        "/widgets/stream": {
+            "get": {
+                "description": "Resolves the widget as ` + "`" + `GET /call` + "`" + ` does and streams it as Server-Sent Events:\nthe widget is resolved again whenever the widget, its RESTAction (apiRef) or the Kubernetes objects\nit reads change. Changes are debounced (waiting at most 5 debounce intervals) and a widget equal to the last one sent is not sent again.\nEach event is a ` + "`" + `RESOLVED` + "`" + ` widget or, when the resolution fails, a ` + "`" + `FAILED` + "`" + ` status; idle streams receive a heartbeat comment.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "summary": "Widget Stream Endpoint",
+                "operationId": "widgets-stream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Widget API Group and Version",
+                        "name": "apiVersion",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Widget Resource Plural",
+                        "name": "resource",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Widget name",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Widget namespace",
+                        "name": "namespace",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "perPage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Extra parameters (JSON object)",
+                        "name": "extras",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of resolved widgets",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Status"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_krateoplatformops_snowplow_apis_templates_v1.EnvVar": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Name of the variable, referenced as $(NAME).",
+                    "type": "string"
+                },
+                "valueFrom": {
+                    "description": "ValueFrom is the source of the value.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_krateoplatformops_snowplow_apis_templates_v1.EnvVarSource"
+                        }
+                    ]
+                }
+            }
+        },
+        "github_com_krateoplatformops_snowplow_apis_templates_v1.EnvVarSource": {
+            "type": "object",
+            "properties": {
+                "configMapKeyRef": {
+                    "description": "ConfigMapKeyRef selects a key of a ConfigMap.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.KeySelector"
+                        }
+                    ]
+                },
+                "secretKeyRef": {
+                    "description": "SecretKeyRef selects a key of a Secret.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.KeySelector"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.batchItem": {
+            "type": "object",
+            "properties": {
+                "apiVersion": {
+                    "type": "string"
+                },
+                "cursor": {
+                    "type": "string"
+                },
+                "extras": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "id": {
+                    "description": "ID identifies the item result (optional).",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "perPage": {
+                    "type": "integer"
+                },
+                "resource": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.batchItemResult": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "object"
+                }
+            }
+        },
+        "handlers.batchin": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.batchItem"
+                    }
+                }
+            }
+        },
+        "handlers.batchout": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.batchItemResult"
+                    }
+                }
+            }
+        },
+        "handlers.jqin": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "query": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.names": {
+            "type": "object",
+            "properties": {
+                "plural": {
+                    "type": "string"
+                },
+                "shorts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "singular": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.serviceInfo": {
+            "type": "object",
+            "properties": {
+                "build": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.validateout": {
+            "type": "object",
+            "properties": {
+                "diagnostics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validate.Diagnostic"
+                    }
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "response.Status": {
+            "type": "object",
+            "properties": {
+                "apiVersion": {
+                    "type": "string"
+                },
+                "code": {
+                    "description": "Suggested HTTP return code for this status, 0 if not set.",
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "message": {
+                    "description": "A human-readable description of the status of this operation.",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "A machine-readable description of why this operation is in the\n\"Failure\" status. If this value is empty there\nis no information available. A Reason clarifies an HTTP status\ncode but does not override it.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.StatusReason"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "Status of the operation.\nOne of: \"Success\" or \"Failure\".",
+                    "type": "string"
+                }
+            }
+        },
+        "response.StatusReason": {
+            "type": "string",
+            "enum": [
+                "",
+                "Unauthorized",
+                "Forbidden",
+                "NotFound",
+                "Conflict",
+                "Gone",
+                "Invalid",
+                "Timeout",
+                "TooManyRequests",
+                "BadRequest",
+                "MethodNotAllowed",
+                "NotAcceptable",
+                "RequestEntityTooLarge",
+                "UnsupportedMediaType",
+                "UnprocessableEntity",
+                "InternalError",
+                "ServiceUnavailable"
+            ],
+            "x-enum-varnames": [
+                "StatusReasonUnknown",
+                "StatusReasonUnauthorized",
+                "StatusReasonForbidden",
+                "StatusReasonNotFound",
+                "StatusReasonConflict",
                 "StatusReasonGone",
                 "StatusReasonInvalid",
                 "StatusReasonTimeout",
@@ -858,6 +1403,760 @@ const docTemplate = `{
                 "StatusReasonInternalError",
                 "StatusReasonServiceUnavailable"
             ]
+        },
+        "runtime.RawExtension": {
+            "type": "object"
+        },
+        "v1.API": {
+            "type": "object",
+            "properties": {
+                "continueOnError": {
+                    "type": "boolean"
+                },
+                "dependsOn": {
+                    "description": "DependsOn references to other APIs on which this depends",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Dependency"
+                        }
+                    ]
+                },
+                "endpointRef": {
+                    "description": "EndpointRef a reference to an Endpoint",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Reference"
+                        }
+                    ]
+                },
+                "env": {
+                    "description": "+listType=map\n+listMapKey=name\nEnv are values read from the Secrets and ConfigMaps of the RESTAction namespace;\neach $(NAME) occurrence in path, headers and payload is replaced by the value.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_krateoplatformops_snowplow_apis_templates_v1.EnvVar"
+                    }
+                },
+                "errorFilter": {
+                    "description": "ErrorFilter is a JQ expression reshaping the failure status\nbefore it is stored under the errorKey.",
+                    "type": "string"
+                },
+                "errorKey": {
+                    "type": "string"
+                },
+                "exportJwt": {
+                    "type": "boolean"
+                },
+                "fallback": {
+                    "description": "Fallback is the value stored under the API name when the API fails;\na string wrapped in ${ } is a JQ expression evaluated against the current context.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/runtime.RawExtension"
+                        }
+                    ]
+                },
+                "filter": {
+                    "type": "string"
+                },
+                "headers": {
+                    "description": "+listType=atomic\nHeaders is an array of custom request headers",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "kubernetes": {
+                    "description": "Kubernetes selects the Kubernetes objects to read, with the user credentials,\nin place of the HTTP call.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.KubernetesResource"
+                        }
+                    ]
+                },
+                "maxConcurrency": {
+                    "description": "MaxConcurrency is the maximum number of concurrent calls\nperformed when iterating over a dependency.",
+                    "type": "integer"
+                },
+                "merge": {
+                    "description": "Merge defines how the results of the API calls (i.e. of the iterator\nelements) are stored under the API name; if omitted the first result\nis stored as is and the next ones are collected into an array.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.MergePolicy"
+                        }
+                    ]
+                },
+                "name": {
+                    "description": "Name is a (unique) identifier",
+                    "type": "string"
+                },
+                "paginate": {
+                    "description": "Paginate describes how to follow the next pages of the response;\nall the pages are concatenated before the filter is applied.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Pagination"
+                        }
+                    ]
+                },
+                "path": {
+                    "description": "Path is the request URI path",
+                    "type": "string"
+                },
+                "payload": {
+                    "description": "Payload is the request body",
+                    "type": "string"
+                },
+                "responseFormat": {
+                    "description": "ResponseFormat is the format of the response body; if omitted\nit is inferred from the response Content-Type (JSON by default).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ResponseFormat"
+                        }
+                    ]
+                },
+                "restActionRef": {
+                    "description": "RESTActionRef references another RESTAction resolved in place of the HTTP call;\nits (filtered) status becomes the value of this API.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.RESTActionReference"
+                        }
+                    ]
+                },
+                "retry": {
+                    "description": "Retry defines how failed calls are retried.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.RetryPolicy"
+                        }
+                    ]
+                },
+                "timeout": {
+                    "description": "Timeout is the maximum duration of all the calls of this API (retries included).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Duration"
+                        }
+                    ]
+                },
+                "verb": {
+                    "description": "Verb is the request method (GET if omitted)",
+                    "type": "string"
+                },
+                "when": {
+                    "description": "When is a JQ expression evaluated against the current context before the call;\nthe API is skipped when it yields false or null.",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.Data": {
+            "type": "object",
+            "properties": {
+                "asString": {
+                    "description": "AsString if true the value will be considered verbatim as string.",
+                    "type": "boolean"
+                },
+                "name": {
+                    "description": "Name of the data",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Value of the data. Can be also a JQ expression.",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.Dependency": {
+            "type": "object",
+            "properties": {
+                "iterator": {
+                    "description": "Iterator defines a field (of the Name API result) on which iterate.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name of another API on which this depends",
+                    "type": "string"
+                },
+                "names": {
+                    "description": "+listType=set\nNames of other APIs on which this depends (together with Name)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "v1.Duration": {
+            "type": "object",
+            "properties": {
+                "time.Duration": {
+                    "type": "integer",
+                    "enum": [
+                        -9223372036854775808,
+                        9223372036854775807,
+                        1,
+                        1000,
+                        1000000,
+                        1000000000,
+                        60000000000,
+                        3600000000000,
+                        1,
+                        1000,
+                        1000000,
+                        1000000000
+                    ],
+                    "x-enum-varnames": [
+                        "minDuration",
+                        "maxDuration",
+                        "Nanosecond",
+                        "Microsecond",
+                        "Millisecond",
+                        "Second",
+                        "Minute",
+                        "Hour",
+                        "Nanosecond",
+                        "Microsecond",
+                        "Millisecond",
+                        "Second"
+                    ]
+                }
+            }
+        },
+        "v1.FieldsV1": {
+            "type": "object"
+        },
+        "v1.KeySelector": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "description": "Key to select.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name of the object.",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.KubernetesResource": {
+            "type": "object",
+            "properties": {
+                "apiVersion": {
+                    "description": "APIVersion is the group version of the resource (e.g. apps/v1).",
+                    "type": "string"
+                },
+                "fieldSelector": {
+                    "description": "FieldSelector restricts the listed objects by their fields.",
+                    "type": "string"
+                },
+                "labelSelector": {
+                    "description": "LabelSelector restricts the listed objects by their labels.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name of the object to get; if omitted the objects are listed.",
+                    "type": "string"
+                },
+                "namespace": {
+                    "description": "Namespace of the objects (ignored for cluster scoped resources).",
+                    "type": "string"
+                },
+                "resource": {
+                    "description": "Resource is the resource name (plural or singular, e.g. deployments).",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ManagedFieldsEntry": {
+            "type": "object",
+            "properties": {
+                "apiVersion": {
+                    "description": "APIVersion defines the version of this resource that this field set\napplies to. The format is \"group/version\" just like the top-level\nAPIVersion field. It is necessary to track the version of a field\nset because it cannot be automatically converted.",
+                    "type": "string"
+                },
+                "fieldsType": {
+                    "description": "FieldsType is the discriminator for the different fields format and version.\nThere is currently only one possible value: \"FieldsV1\"",
+                    "type": "string"
+                },
+                "fieldsV1": {
+                    "description": "FieldsV1 holds the first JSON version format as described in the \"FieldsV1\" type.\n+optional",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.FieldsV1"
+                        }
+                    ]
+                },
+                "manager": {
+                    "description": "Manager is an identifier of the workflow managing these fields.",
+                    "type": "string"
+                },
+                "operation": {
+                    "description": "Operation is the type of operation which lead to this ManagedFieldsEntry being created.\nThe only valid values for this field are 'Apply' and 'Update'.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ManagedFieldsOperationType"
+                        }
+                    ]
+                },
+                "subresource": {
+                    "description": "Subresource is the name of the subresource used to update that object, or\nempty string if the object was updated through the main resource. The\nvalue of this field is used to distinguish between managers, even if they\nshare the same name. For example, a status update will be distinct from a\nregular update using the same manager name.\nNote that the APIVersion field is not related to the Subresource field and\nit always corresponds to the version of the main resource.",
+                    "type": "string"
+                },
+                "time": {
+                    "description": "Time is the timestamp of when the ManagedFields entry was added. The\ntimestamp will also be updated if a field is added, the manager\nchanges any of the owned fields value or removes a field. The\ntimestamp does not update when a field is removed from the entry\nbecause another manager took it over.\n+optional",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ManagedFieldsOperationType": {
+            "type": "string",
+            "enum": [
+                "Apply",
+                "Update"
+            ],
+            "x-enum-varnames": [
+                "ManagedFieldsOperationApply",
+                "ManagedFieldsOperationUpdate"
+            ]
+        },
+        "v1.MergePolicy": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "description": "Key is the JQ expression evaluated on each item identifying it (keyed strategy only).",
+                    "type": "string"
+                },
+                "strategy": {
+                    "description": "Strategy is the merge strategy.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.MergeStrategy"
+                        }
+                    ]
+                }
+            }
+        },
+        "v1.MergeStrategy": {
+            "type": "string",
+            "enum": [
+                "array",
+                "replace",
+                "concat",
+                "deepMerge",
+                "keyed"
+            ],
+            "x-enum-varnames": [
+                "MergeArray",
+                "MergeReplace",
+                "MergeConcat",
+                "MergeDeepMerge",
+                "MergeKeyed"
+            ]
+        },
+        "v1.ObjectMeta": {
+            "type": "object",
+            "properties": {
+                "annotations": {
+                    "description": "Annotations is an unstructured key value map stored with a resource that may be\nset by external tools to store and retrieve arbitrary metadata. They are not\nqueryable and should be preserved when modifying objects.\nMore info: https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations\n+optional",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "creationTimestamp": {
+                    "description": "CreationTimestamp is a timestamp representing the server time when this object was\ncreated. It is not guaranteed to be set in happens-before order across separate operations.\nClients may not set this value. It is represented in RFC3339 form and is in UTC.\n\nPopulated by the system.\nRead-only.\nNull for lists.\nMore info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata\n+optional",
+                    "type": "string"
+                },
+                "deletionGracePeriodSeconds": {
+                    "description": "Number of seconds allowed for this object to gracefully terminate before\nit will be removed from the system. Only set when deletionTimestamp is also set.\nMay only be shortened.\nRead-only.\n+optional",
+                    "type": "integer"
+                },
+                "deletionTimestamp": {
+                    "description": "DeletionTimestamp is RFC 3339 date and time at which this resource will be deleted. This\nfield is set by the server when a graceful deletion is requested by the user, and is not\ndirectly settable by a client. The resource is expected to be deleted (no longer visible\nfrom resource lists, and not reachable by name) after the time in this field, once the\nfinalizers list is empty. As long as the finalizers list contains items, deletion is blocked.\nOnce the deletionTimestamp is set, this value may not be unset or be set further into the\nfuture, although it may be shortened or the resource may be deleted prior to this time.\nFor example, a user may request that a pod is deleted in 30 seconds. The Kubelet will react\nby sending a graceful termination signal to the containers in the pod. After that 30 seconds,\nthe Kubelet will send a hard termination signal (SIGKILL) to the container and after cleanup,\nremove the pod from the API. In the presence of network partitions, this object may still\nexist after this timestamp, until an administrator or automated process can determine the\nresource is fully terminated.\nIf not set, graceful deletion of the object has not been requested.\n\nPopulated by the system when a graceful deletion is requested.\nRead-only.\nMore info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata\n+optional",
+                    "type": "string"
+                },
+                "finalizers": {
+                    "description": "Must be empty before the object is deleted from the registry. Each entry\nis an identifier for the responsible component that will remove the entry\nfrom the list. If the deletionTimestamp of the object is non-nil, entries\nin this list can only be removed.\nFinalizers may be processed and removed in any order.  Order is NOT enforced\nbecause it introduces significant risk of stuck finalizers.\nfinalizers is a shared field, any actor with permission can reorder it.\nIf the finalizer list is processed in order, then this can lead to a situation\nin which the component responsible for the first finalizer in the list is\nwaiting for a signal (field value, external system, or other) produced by a\ncomponent responsible for a finalizer later in the list, resulting in a deadlock.\nWithout enforced ordering finalizers are free to order amongst themselves and\nare not vulnerable to ordering changes in the list.\n+optional\n+patchStrategy=merge\n+listType=set",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "generateName": {
+                    "description": "GenerateName is an optional prefix, used by the server, to generate a unique\nname ONLY IF the Name field has not been provided.\nIf this field is used, the name returned to the client will be different\nthan the name passed. This value will also be combined with a unique suffix.\nThe provided value has the same validation rules as the Name field,\nand may be truncated by the length of the suffix required to make the value\nunique on the server.\n\nIf this field is specified and the generated name exists, the server will return a 409.\n\nApplied only if Name is not specified.\nMore info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#idempotency\n+optional",
+                    "type": "string"
+                },
+                "generation": {
+                    "description": "A sequence number representing a specific generation of the desired state.\nPopulated by the system. Read-only.\n+optional",
+                    "type": "integer"
+                },
+                "labels": {
+                    "description": "Map of string keys and values that can be used to organize and categorize\n(scope and select) objects. May match selectors of replication controllers\nand services.\nMore info: https://kubernetes.io/docs/concepts/overview/working-with-objects/labels\n+optional",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "managedFields": {
+                    "description": "ManagedFields maps workflow-id and version to the set of fields\nthat are managed by that workflow. This is mostly for internal\nhousekeeping, and users typically shouldn't need to set or\nunderstand this field. A workflow can be the user's name, a\ncontroller's name, or the name of a specific apply path like\n\"ci-cd\". The set of fields is always in the version that the\nworkflow used when modifying the object.\n\n+optional\n+listType=atomic",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ManagedFieldsEntry"
+                    }
+                },
+                "name": {
+                    "description": "Name must be unique within a namespace. Is required when creating resources, although\nsome resources may allow a client to request the generation of an appropriate name\nautomatically. Name is primarily intended for creation idempotence and configuration\ndefinition.\nCannot be updated.\nMore info: https://kubernetes.io/docs/concepts/overview/working-with-objects/names#names\n+optional",
+                    "type": "string"
+                },
+                "namespace": {
+                    "description": "Namespace defines the space within which each name must be unique. An empty namespace is\nequivalent to the \"default\" namespace, but \"default\" is the canonical representation.\nNot all objects are required to be scoped to a namespace - the value of this field for\nthose objects will be empty.\n\nMust be a DNS_LABEL.\nCannot be updated.\nMore info: https://kubernetes.io/docs/concepts/overview/working-with-objects/namespaces\n+optional",
+                    "type": "string"
+                },
+                "ownerReferences": {
+                    "description": "List of objects depended by this object. If ALL objects in the list have\nbeen deleted, this object will be garbage collected. If this object is managed by a controller,\nthen an entry in this list will point to this controller, with the controller field set to true.\nThere cannot be more than one managing controller.\n+optional\n+patchMergeKey=uid\n+patchStrategy=merge\n+listType=map\n+listMapKey=uid",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.OwnerReference"
+                    }
+                },
+                "resourceVersion": {
+                    "description": "An opaque value that represents the internal version of this object that can\nbe used by clients to determine when objects have changed. May be used for optimistic\nconcurrency, change detection, and the watch operation on a resource or set of resources.\nClients must treat these values as opaque and passed unmodified back to the server.\nThey may only be valid for a particular resource or set of resources.\n\nPopulated by the system.\nRead-only.\nValue must be treated as opaque by clients and .\nMore info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#concurrency-control-and-consistency\n+optional",
+                    "type": "string"
+                },
+                "selfLink": {
+                    "description": "Deprecated: selfLink is a legacy read-only field that is no longer populated by the system.\n+optional",
+                    "type": "string"
+                },
+                "uid": {
+                    "description": "UID is the unique in time and space value for this object. It is typically generated by\nthe server on successful creation of a resource and is not allowed to change on PUT\noperations.\n\nPopulated by the system.\nRead-only.\nMore info: https://kubernetes.io/docs/concepts/overview/working-with-objects/names#uids\n+optional",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.OwnerReference": {
+            "type": "object",
+            "properties": {
+                "apiVersion": {
+                    "description": "API version of the referent.",
+                    "type": "string"
+                },
+                "blockOwnerDeletion": {
+                    "description": "If true, AND if the owner has the \"foregroundDeletion\" finalizer, then\nthe owner cannot be deleted from the key-value store until this\nreference is removed.\nSee https://kubernetes.io/docs/concepts/architecture/garbage-collection/#foreground-deletion\nfor how the garbage collector interacts with this field and enforces the foreground deletion.\nDefaults to false.\nTo set this field, a user needs \"delete\" permission of the owner,\notherwise 422 (Unprocessable Entity) will be returned.\n+optional",
+                    "type": "boolean"
+                },
+                "controller": {
+                    "description": "If true, this reference points to the managing controller.\n+optional",
+                    "type": "boolean"
+                },
+                "kind": {
+                    "description": "Kind of the referent.\nMore info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name of the referent.\nMore info: https://kubernetes.io/docs/concepts/overview/working-with-objects/names#names",
+                    "type": "string"
+                },
+                "uid": {
+                    "description": "UID of the referent.\nMore info: https://kubernetes.io/docs/concepts/overview/working-with-objects/names#uids",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.Pagination": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "description": "Items is a JQ expression extracting the items from each page (the whole page if omitted).",
+                    "type": "string"
+                },
+                "maxPages": {
+                    "description": "MaxPages is the maximum number of requested pages (100 if omitted).",
+                    "type": "integer"
+                },
+                "next": {
+                    "description": "Next is a JQ expression returning the next cursor or continue token from\neach page (cursor type only); pagination stops when it yields null or an empty string.",
+                    "type": "string"
+                },
+                "param": {
+                    "description": "Param is the query parameter carrying the cursor, page number or offset\n(defaults to \"page\" and \"offset\" for the page and offset types).",
+                    "type": "string"
+                },
+                "start": {
+                    "description": "Start is the first page number or offset (1 for page, 0 for offset).",
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "Type is the pagination strategy.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.PaginationType"
+                        }
+                    ]
+                }
+            }
+        },
+        "v1.PaginationType": {
+            "type": "string",
+            "enum": [
+                "link",
+                "cursor",
+                "page",
+                "offset"
+            ],
+            "x-enum-varnames": [
+                "PaginationLink",
+                "PaginationCursor",
+                "PaginationPage",
+                "PaginationOffset"
+            ]
+        },
+        "v1.Param": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "Default is the value of the parameter when not set.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/runtime.RawExtension"
+                        }
+                    ]
+                },
+                "description": {
+                    "description": "Description of the parameter.",
+                    "type": "string"
+                },
+                "enum": {
+                    "description": "Enum are the allowed values (of string, integer, number and boolean parameters).\n+listType=atomic",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "description": "Name of the parameter (the extras key).",
+                    "type": "string"
+                },
+                "pattern": {
+                    "description": "Pattern is the regular expression matched by string parameters.",
+                    "type": "string"
+                },
+                "required": {
+                    "description": "Required parameters must be set if they have no default.",
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "Type of the parameter (string if omitted).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ParamType"
+                        }
+                    ]
+                }
+            }
+        },
+        "v1.ParamType": {
+            "type": "string",
+            "enum": [
+                "string",
+                "integer",
+                "number",
+                "boolean",
+                "array",
+                "object"
+            ],
+            "x-enum-varnames": [
+                "ParamTypeString",
+                "ParamTypeInteger",
+                "ParamTypeNumber",
+                "ParamTypeBoolean",
+                "ParamTypeArray",
+                "ParamTypeObject"
+            ]
+        },
+        "v1.RESTAction": {
+            "type": "object",
+            "properties": {
+                "apiVersion": {
+                    "description": "APIVersion defines the versioned schema of this representation of an object.\nServers should convert recognized schemas to the latest internal value, and\nmay reject unrecognized values.\nMore info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources\n+optional",
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "Kind is a string value representing the REST resource this object represents.\nServers may infer this from the endpoint the client submits requests to.\nCannot be updated.\nIn CamelCase.\nMore info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds\n+optional",
+                    "type": "string"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/v1.ObjectMeta"
+                },
+                "spec": {
+                    "$ref": "#/definitions/v1.RESTActionSpec"
+                },
+                "status": {
+                    "$ref": "#/definitions/runtime.RawExtension"
+                }
+            }
+        },
+        "v1.RESTActionReference": {
+            "type": "object",
+            "properties": {
+                "extras": {
+                    "description": "+listType=atomic\nExtras are the parameters passed to the referenced RESTAction;\nvalues can be JQ expressions evaluated against the current context.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.Data"
+                    }
+                },
+                "name": {
+                    "description": "Name of the referenced object.",
+                    "type": "string"
+                },
+                "namespace": {
+                    "description": "Namespace of the referenced object.",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.RESTActionSpec": {
+            "type": "object",
+            "properties": {
+                "api": {
+                    "description": "+listType=atomic",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.API"
+                    }
+                },
+                "filter": {
+                    "type": "string"
+                },
+                "outputSchema": {
+                    "description": "OutputSchema is the OpenAPI v3 schema of the resolved status\n(the filter result); a status not matching the schema is an error.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/runtime.RawExtension"
+                        }
+                    ]
+                },
+                "params": {
+                    "description": "Params are the declared input parameters, read from the extras;\nthey are validated and defaulted before resolving the APIs.\n+listType=map\n+listMapKey=name",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.Param"
+                    }
+                },
+                "timeout": {
+                    "description": "Timeout is the overall deadline for resolving all the APIs.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Duration"
+                        }
+                    ]
+                }
+            }
+        },
+        "v1.Reference": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Name of the referenced object.",
+                    "type": "string"
+                },
+                "namespace": {
+                    "description": "Namespace of the referenced object.",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ResponseFormat": {
+            "type": "string",
+            "enum": [
+                "json",
+                "yaml",
+                "xml",
+                "csv",
+                "text"
+            ],
+            "x-enum-varnames": [
+                "ResponseFormatJSON",
+                "ResponseFormatYAML",
+                "ResponseFormatXML",
+                "ResponseFormatCSV",
+                "ResponseFormatText"
+            ]
+        },
+        "v1.RetryErrorClass": {
+            "type": "string",
+            "enum": [
+                "timeout",
+                "connection",
+                "dns"
+            ],
+            "x-enum-varnames": [
+                "RetryOnTimeout",
+                "RetryOnConnection",
+                "RetryOnDNS"
+            ]
+        },
+        "v1.RetryPolicy": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "description": "+listType=atomic\nErrors are the classes of transport errors that trigger a retry.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RetryErrorClass"
+                    }
+                },
+                "initialBackoff": {
+                    "description": "InitialBackoff is the delay before the first retry (500ms if omitted);\nit doubles at each attempt, with a random jitter.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Duration"
+                        }
+                    ]
+                },
+                "maxAttempts": {
+                    "description": "MaxAttempts is the maximum number of attempts, including the first one.",
+                    "type": "integer"
+                },
+                "maxBackoff": {
+                    "description": "MaxBackoff is the upper bound of the delay between attempts (10s if omitted).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Duration"
+                        }
+                    ]
+                },
+                "statusCodes": {
+                    "description": "+listType=atomic\nStatusCodes are the response status codes that trigger a retry.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "validate.Diagnostic": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Path is the path of the field (e.g. spec.api[1].dependsOn[0].name).",
+                    "type": "string"
+                },
+                "severity": {
+                    "$ref": "#/definitions/validate.Severity"
+                }
+            }
+        },
+        "validate.Severity": {
+            "type": "string",
+            "enum": [
+                "error",
+                "warning"
+            ],
+            "x-enum-varnames": [
+                "SeverityError",
+                "SeverityWarning"
+            ]
         }
     }
 }`
@@ -0,0 +1,149 @@
+package dynamic
+
+import (
+	"context"
+	"sync"
+
+	"github.com/krateoplatformops/plumbing/endpoints"
+	"github.com/krateoplatformops/plumbing/kubeconfig"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// Cache shares the client configs and the dynamic clients (together with
+// their discovery cache) among all the resolutions of the same request.
+type Cache struct {
+	mu      sync.Mutex
+	configs map[endpoints.Endpoint]*rest.Config
+	clients map[*rest.Config]*unstructuredClient
+	// inCluster is the service account config (loaded once).
+	inCluster *rest.Config
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		configs: map[endpoints.Endpoint]*rest.Config{},
+		clients: map[*rest.Config]*unstructuredClient{},
+	}
+}
+
+type cacheKey struct{}
+
+func WithCache(ctx context.Context, c *Cache) context.Context {
+	return context.WithValue(ctx, cacheKey{}, c)
+}
+
+// CacheFromContext returns the cache carried by the context (nil if none).
+func CacheFromContext(ctx context.Context) *Cache {
+	c, _ := ctx.Value(cacheKey{}).(*Cache)
+	return c
+}
+
+// ClientConfig returns the client config for the endpoint; with a cache in
+// the context the same config is returned for the same endpoint.
+// The returned config must not be modified, use rest.CopyConfig instead.
+func ClientConfig(ctx context.Context, ep endpoints.Endpoint) (*rest.Config, error) {
+	c := CacheFromContext(ctx)
+	if c == nil {
+		return kubeconfig.NewClientConfig(ctx, ep)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if rc, ok := c.configs[ep]; ok {
+		return rc, nil
+	}
+
+	rc, err := kubeconfig.NewClientConfig(ctx, ep)
+	if err != nil {
+		return nil, err
+	}
+	c.configs[ep] = rc
+	return rc, nil
+}
+
+// InClusterConfig returns the service account client config; with a cache
+// in the context the same config is returned, so that its client is shared.
+// The returned config must not be modified, use rest.CopyConfig instead.
+func InClusterConfig(ctx context.Context) (*rest.Config, error) {
+	c := CacheFromContext(ctx)
+	if c == nil {
+		return rest.InClusterConfig()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.inCluster != nil {
+		return c.inCluster, nil
+	}
+
+	rc, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	c.inCluster = rc
+	return rc, nil
+}
+
+// ClientFor returns the client for the config; with a cache in the
+// context the same client is returned for the same config.
+func ClientFor(ctx context.Context, rc *rest.Config) (Client, error) {
+	c := CacheFromContext(ctx)
+	if c == nil {
+		return NewClient(rc)
+	}
+	return c.client(rc)
+}
+
+// KindForContext is like KindFor, but it uses the discovery cache
+// of the client shared through the context cache (if any).
+func KindForContext(ctx context.Context, rc *rest.Config, gvr schema.GroupVersionResource) (schema.GroupVersionKind, error) {
+	c := CacheFromContext(ctx)
+	if c == nil {
+		return KindFor(rc, gvr)
+	}
+
+	cli, err := c.client(rc)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return cli.mapper.KindFor(gvr)
+}
+
+// ResourceForContext is like ResourceFor, but it uses the discovery cache
+// of the client shared through the context cache (if any).
+func ResourceForContext(ctx context.Context, rc *rest.Config, gvk schema.GroupVersionKind) (schema.GroupVersionResource, error) {
+	c := CacheFromContext(ctx)
+	if c == nil || rc == nil {
+		return ResourceFor(rc, gvk)
+	}
+
+	cli, err := c.client(rc)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+
+	mapping, err := cli.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	return mapping.Resource, nil
+}
+
+func (c *Cache) client(rc *rest.Config) (*unstructuredClient, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cli, ok := c.clients[rc]; ok {
+		return cli, nil
+	}
+
+	cli, err := newClient(rc)
+	if err != nil {
+		return nil, err
+	}
+	c.clients[rc] = cli
+	return cli, nil
+}
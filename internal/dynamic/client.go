@@ -4,10 +4,13 @@ import (
 	"context"
 	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
 	cacheddiscovery "k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
@@ -16,6 +19,10 @@ import (
 )
 
 func NewClient(rc *rest.Config) (Client, error) {
+	return newClient(rc)
+}
+
+func newClient(rc *rest.Config) (*unstructuredClient, error) {
 	dynamicClient, err := dynamic.NewForConfig(rc)
 	if err != nil {
 		return nil, err
@@ -42,11 +49,17 @@ type Options struct {
 	Namespace string
 	GVK       schema.GroupVersionKind
 	GVR       schema.GroupVersionResource
+	// LabelSelector and FieldSelector restrict the listed objects.
+	LabelSelector string
+	FieldSelector string
+	// ResourceVersion is the version from which the changes are watched.
+	ResourceVersion string
 }
 
 type Client interface {
 	Get(ctx context.Context, name string, opts Options) (*unstructured.Unstructured, error)
 	List(ctx context.Context, opts Options) (*unstructured.UnstructuredList, error)
+	Watch(ctx context.Context, opts Options) (watch.Interface, error)
 	Create(ctx context.Context, obj *unstructured.Unstructured, opts Options) (*unstructured.Unstructured, error)
 	Delete(ctx context.Context, name string, opts Options) error
 	FromUnstructured(in map[string]any, out any) error
@@ -78,7 +91,14 @@ func (uc *unstructuredClient) Get(ctx context.Context, name string, opts Options
 		return nil, err
 	}
 
-	return ri.Get(ctx, name, metav1.GetOptions{})
+	res, err := ri.Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		ReadsFromContext(ctx).Add(readOf(name, opts), res.GetResourceVersion())
+	} else if apierrors.IsNotFound(err) {
+		// the object may be created later
+		ReadsFromContext(ctx).Add(readOf(name, opts), "")
+	}
+	return res, err
 }
 
 func (uc *unstructuredClient) List(ctx context.Context, opts Options) (*unstructured.UnstructuredList, error) {
@@ -87,7 +107,30 @@ func (uc *unstructuredClient) List(ctx context.Context, opts Options) (*unstruct
 		return nil, err
 	}
 
-	return ri.List(ctx, metav1.ListOptions{})
+	res, err := ri.List(ctx, metav1.ListOptions{
+		LabelSelector: opts.LabelSelector,
+		FieldSelector: opts.FieldSelector,
+	})
+	if err == nil {
+		ReadsFromContext(ctx).Add(readOf("", opts), res.GetResourceVersion())
+	}
+	return res, err
+}
+
+// Watch watches the changes of the selected objects; bookmarks
+// are requested to keep the last resource version up to date.
+func (uc *unstructuredClient) Watch(ctx context.Context, opts Options) (watch.Interface, error) {
+	ri, err := uc.resourceInterfaceFor(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return ri.Watch(ctx, metav1.ListOptions{
+		LabelSelector:       opts.LabelSelector,
+		FieldSelector:       opts.FieldSelector,
+		ResourceVersion:     opts.ResourceVersion,
+		AllowWatchBookmarks: true,
+	})
 }
 
 func (uc *unstructuredClient) Delete(ctx context.Context, name string, opts Options) error {
@@ -148,8 +191,11 @@ func (uc *unstructuredClient) resourceInterfaceFor(opts Options) (dynamic.Resour
 		return nil, err
 	}
 
+	// cluster scoped resources ignore the namespace
+	isNamespaced := restMapping.Scope.Name() == meta.RESTScopeNameNamespace
+
 	var ri dynamic.ResourceInterface
-	if len(opts.Namespace) == 0 {
+	if len(opts.Namespace) == 0 || !isNamespaced {
 		ri = uc.dynamicClient.Resource(restMapping.Resource)
 	} else {
 		ri = uc.dynamicClient.Resource(restMapping.Resource).
@@ -158,6 +204,16 @@ func (uc *unstructuredClient) resourceInterfaceFor(opts Options) (dynamic.Resour
 	return ri, nil
 }
 
+func readOf(name string, opts Options) Read {
+	return Read{
+		GVR:           opts.GVR,
+		Namespace:     opts.Namespace,
+		Name:          name,
+		LabelSelector: opts.LabelSelector,
+		FieldSelector: opts.FieldSelector,
+	}
+}
+
 func found(el metav1.APIResource, str string) bool {
 	if strings.EqualFold(el.Name, str) {
 		return true
@@ -0,0 +1,146 @@
+package dynamic
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Read identifies the objects read by a Get (Name set) or a List.
+type Read struct {
+	GVR           schema.GroupVersionResource
+	Namespace     string
+	Name          string
+	LabelSelector string
+	FieldSelector string
+}
+
+// Reads records the objects read by the clients (i.e. the dependencies
+// of a widget resolution) together with the resource version they had;
+// it is safe for concurrent use. A nil Reads records nothing.
+type Reads struct {
+	mu    sync.Mutex
+	items map[Read]string
+}
+
+func NewReads() *Reads {
+	return &Reads{items: map[Read]string{}}
+}
+
+type readsKey struct{}
+
+func WithReads(ctx context.Context, r *Reads) context.Context {
+	return context.WithValue(ctx, readsKey{}, r)
+}
+
+// ReadsFromContext returns the recorder carried by the context (nil if none).
+func ReadsFromContext(ctx context.Context) *Reads {
+	r, _ := ctx.Value(readsKey{}).(*Reads)
+	return r
+}
+
+// Add records the read; the last resource version wins.
+func (r *Reads) Add(el Read, resourceVersion string) {
+	if r == nil || el.GVR.Empty() {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items[el] = resourceVersion
+}
+
+// WatchOptions returns, sorted, the options to watch the changes of the
+// recorded reads since they have been read.
+func (r *Reads) WatchOptions() []Options {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := make([]Options, 0, len(r.items))
+	for el, rv := range r.items {
+		opts := Options{
+			GVR:             el.GVR,
+			Namespace:       el.Namespace,
+			LabelSelector:   el.LabelSelector,
+			FieldSelector:   el.FieldSelector,
+			ResourceVersion: rv,
+		}
+		if len(el.Name) > 0 {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", el.Name).String()
+		}
+		res = append(res, opts)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.GVR != b.GVR {
+			return a.GVR.String() < b.GVR.String()
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.FieldSelector != b.FieldSelector {
+			return a.FieldSelector < b.FieldSelector
+		}
+		return a.LabelSelector < b.LabelSelector
+	})
+
+	return res
+}
+
+// ReadOfPath returns the objects read by a GET of the Kubernetes API path
+// (i.e. `/api/v1/namespaces/demo/configmaps/nginx`) honoring the label and
+// field selectors of its query; false if the path does not read objects
+// (i.e. discovery, subresources, watches).
+func ReadOfPath(path string) (Read, bool) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return Read{}, false
+	}
+
+	q := u.Query()
+	if q.Get("watch") == "true" || q.Get("watch") == "1" {
+		return Read{}, false
+	}
+
+	var el Read
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(segs) > 2 && segs[0] == "api":
+		el.GVR.Version, segs = segs[1], segs[2:]
+	case len(segs) > 3 && segs[0] == "apis":
+		el.GVR.Group, el.GVR.Version, segs = segs[1], segs[2], segs[3:]
+	default:
+		return Read{}, false
+	}
+
+	if len(segs) > 2 && segs[0] == "namespaces" {
+		el.Namespace, segs = segs[1], segs[2:]
+	}
+
+	switch len(segs) {
+	case 1:
+		el.GVR.Resource = segs[0]
+		el.LabelSelector = q.Get("labelSelector")
+		el.FieldSelector = q.Get("fieldSelector")
+	case 2:
+		el.GVR.Resource, el.Name = segs[0], segs[1]
+	default:
+		return Read{}, false
+	}
+
+	if len(el.GVR.Resource) == 0 || len(el.GVR.Version) == 0 {
+		return Read{}, false
+	}
+
+	return el, true
+}
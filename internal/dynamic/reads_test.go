@@ -0,0 +1,68 @@
+package dynamic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestReads(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	widgets := schema.GroupVersionResource{Group: "widgets.templates.krateo.io", Version: "v1beta1", Resource: "tables"}
+
+	reads := NewReads()
+	ctx := WithReads(context.Background(), reads)
+
+	ReadsFromContext(ctx).Add(readOf("pods", Options{GVR: widgets, Namespace: "demo"}), "10")
+	ReadsFromContext(ctx).Add(readOf("", Options{GVR: pods, Namespace: "demo", LabelSelector: "app=web"}), "12")
+	// the last resource version wins
+	ReadsFromContext(ctx).Add(readOf("pods", Options{GVR: widgets, Namespace: "demo"}), "15")
+	// without a resource there is nothing to watch
+	ReadsFromContext(ctx).Add(readOf("nginx", Options{Namespace: "demo"}), "1")
+
+	want := []Options{
+		{GVR: pods, Namespace: "demo", LabelSelector: "app=web", ResourceVersion: "12"},
+		{GVR: widgets, Namespace: "demo", FieldSelector: "metadata.name=pods", ResourceVersion: "15"},
+	}
+	if got := reads.WatchOptions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	// without a recorder nothing is recorded
+	ReadsFromContext(context.Background()).Add(readOf("nginx", Options{GVR: pods}), "1")
+	if got := ReadsFromContext(context.Background()).WatchOptions(); got != nil {
+		t.Fatalf("expected no options, got %+v", got)
+	}
+}
+
+func TestReadOfPath(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	tests := []struct {
+		path string
+		want Read
+		ok   bool
+	}{
+		{path: "/api/v1/namespaces/kube-system/pods", want: Read{GVR: pods, Namespace: "kube-system"}, ok: true},
+		{path: "/api/v1/namespaces/kube-system/pods/etcd", want: Read{GVR: pods, Namespace: "kube-system", Name: "etcd"}, ok: true},
+		{path: "/api/v1/pods?labelSelector=app%3Dweb&limit=10", want: Read{GVR: pods, LabelSelector: "app=web"}, ok: true},
+		{path: "/apis/apps/v1/namespaces/demo/deployments/web", want: Read{GVR: deployments, Namespace: "demo", Name: "web"}, ok: true},
+		{path: "/api/v1/namespaces/demo", want: Read{GVR: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, Name: "demo"}, ok: true},
+		{path: "/api/v1/namespaces/demo/pods/web/log"},
+		{path: "/api/v1/pods?watch=true"},
+		{path: "/apis/apps/v1"},
+		{path: "/version"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			got, ok := ReadOfPath(tc.path)
+			if ok != tc.ok || got != tc.want {
+				t.Fatalf("expected %+v (%v), got %+v (%v)", tc.want, tc.ok, got, ok)
+			}
+		})
+	}
+}
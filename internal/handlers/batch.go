@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/env"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
+	"github.com/krateoplatformops/snowplow/internal/handlers/util"
+)
+
+const (
+	// EnvBatchMaxConcurrency is the environment variable holding the maximum
+	// number of batch items resolved concurrently.
+	EnvBatchMaxConcurrency = "BATCH_MAX_CONCURRENCY"
+	// EnvBatchMaxItems is the environment variable holding the maximum
+	// number of items of a single batch request.
+	EnvBatchMaxItems = "BATCH_MAX_ITEMS"
+
+	defaultBatchMaxConcurrency = 8
+	defaultBatchMaxItems       = 100
+)
+
+// Batch resolves many objects in a single request: each item is served
+// by the next handler (i.e. the dispatchers) as a `GET /call` request.
+func Batch(next http.Handler) http.Handler {
+	return &batchHandler{
+		next:           next,
+		maxConcurrency: env.Int(EnvBatchMaxConcurrency, defaultBatchMaxConcurrency),
+		maxItems:       env.Int(EnvBatchMaxItems, defaultBatchMaxItems),
+	}
+}
+
+var _ http.Handler = (*batchHandler)(nil)
+
+type batchHandler struct {
+	next           http.Handler
+	maxConcurrency int
+	maxItems       int
+}
+
+// @Summary     Resolve many objects in one request
+// @Description This endpoint accepts a list of object references (i.e. widgets and RESTActions),
+// @Description each one with its own pagination and extras, and resolves them concurrently as `GET /call` does.
+// @Description The Kubernetes clients are shared among all the items.
+// @Description It returns, in the same order, the status code and the result (or the failure status) of each item.
+// @Tags        batch
+// @Accept      json
+// @Produce     json
+// @Param       body  body   batchin   true  "Objects to resolve"
+// @Success     200   {object}  batchout  "Results of each item"
+// @Failure 400 {object} response.Status
+// @Failure 401 {object} response.Status
+// @Failure 406 {object} response.Status
+// @Router      /batch [post]
+func (r *batchHandler) ServeHTTP(wri http.ResponseWriter, req *http.Request) {
+	log := xcontext.Logger(req.Context())
+
+	start := time.Now()
+
+	if _, err := xcontext.UserConfig(req.Context()); err != nil {
+		log.Error("unable to get user endpoint", slog.Any("err", err))
+		response.Unauthorized(wri, err)
+		return
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	if !strings.Contains(contentType, "application/json") {
+		err := fmt.Errorf("unsupported content type '%s' use 'application/json'", contentType)
+		log.Error(err.Error())
+		response.NotAcceptable(wri, err)
+		return
+	}
+
+	in := &batchin{}
+	if err := json.NewDecoder(io.LimitReader(req.Body, MaxBodySize)).Decode(in); err != nil {
+		log.Error("unable to decode batch request", slog.Any("err", err))
+		response.BadRequest(wri, err)
+		return
+	}
+	if len(in.Items) == 0 {
+		response.BadRequest(wri, fmt.Errorf("no items to resolve"))
+		return
+	}
+	if r.maxItems > 0 && len(in.Items) > r.maxItems {
+		response.BadRequest(wri, fmt.Errorf("too many items: %d (max %d)", len(in.Items), r.maxItems))
+		return
+	}
+
+	// the items share the user clients and the discovery cache
+	ctx := dynamic.WithCache(req.Context(), dynamic.NewCache())
+
+	out := batchout{Items: make([]batchItemResult, len(in.Items))}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(r.maxConcurrency, 1))
+	for i, el := range in.Items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			out.Items[i] = r.resolveItem(req.WithContext(ctx), el)
+		}()
+	}
+	wg.Wait()
+
+	log.Info("batch successfully resolved",
+		slog.Int("items", len(in.Items)),
+		slog.String("duration", util.ETA(start)),
+	)
+
+	wri.Header().Set("Content-Type", "application/json")
+	wri.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(wri)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(&out); err != nil {
+		log.Error("unable to serve batch response", slog.Any("err", err))
+	}
+}
+
+// resolveItem serves the item as a `GET /call` request and collects the response.
+func (r *batchHandler) resolveItem(req *http.Request, in batchItem) batchItemResult {
+	res := batchItemResult{ID: in.ID}
+
+	query, err := in.query()
+	if err != nil {
+		return res.fail(response.New(http.StatusBadRequest, err))
+	}
+
+	sub, err := http.NewRequestWithContext(req.Context(), http.MethodGet, "/call?"+query.Encode(), nil)
+	if err != nil {
+		return res.fail(response.New(http.StatusInternalServerError, err))
+	}
+	sub.Header = req.Header.Clone()
+	sub.Header.Del("Content-Type")
+
+	rec := &bufferedWriter{header: http.Header{}}
+	r.next.ServeHTTP(rec, sub)
+
+	dat := bytes.TrimSpace(rec.buf.Bytes())
+	if !json.Valid(dat) {
+		return res.fail(response.New(http.StatusInternalServerError,
+			fmt.Errorf("invalid response: %s", string(dat))))
+	}
+
+	res.Code = rec.statusCode()
+
+	// failures (i.e. the failed steps) are kept as they are
+	if isStatus(dat) {
+		res.Status = dat
+		return res
+	}
+
+	res.Result = dat
+	return res
+}
+
+// isStatus reports whether the response is a failure status.
+func isStatus(dat []byte) bool {
+	var obj struct {
+		Kind string `json:"kind"`
+	}
+	err := json.Unmarshal(dat, &obj)
+	return err == nil && obj.Kind == "Status"
+}
+
+// query returns the `GET /call` query parameters of the item.
+func (in *batchItem) query() (url.Values, error) {
+	res := url.Values{}
+	res.Set("apiVersion", in.APIVersion)
+	res.Set("resource", in.Resource)
+	res.Set("name", in.Name)
+	res.Set("namespace", in.Namespace)
+
+	if in.PerPage > 0 {
+		res.Set("perPage", strconv.Itoa(in.PerPage))
+	}
+	if in.Page > 0 {
+		res.Set("page", strconv.Itoa(in.Page))
+	}
+	if len(in.Cursor) > 0 {
+		res.Set("cursor", in.Cursor)
+	}
+
+	if len(in.Extras) > 0 {
+		dat, err := json.Marshal(in.Extras)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'extras': %w", err)
+		}
+		res.Set("extras", string(dat))
+	}
+
+	return res, nil
+}
+
+type batchin struct {
+	Items []batchItem `json:"items"`
+}
+
+// batchItem is the reference to an object to resolve, with the
+// same parameters of the `GET /call` endpoint.
+type batchItem struct {
+	// ID identifies the item result (optional).
+	ID         string         `json:"id,omitempty"`
+	APIVersion string         `json:"apiVersion"`
+	Resource   string         `json:"resource"`
+	Name       string         `json:"name"`
+	Namespace  string         `json:"namespace"`
+	Page       int            `json:"page,omitempty"`
+	PerPage    int            `json:"perPage,omitempty"`
+	Cursor     string         `json:"cursor,omitempty"`
+	Extras     map[string]any `json:"extras,omitempty"`
+}
+
+type batchout struct {
+	Items []batchItemResult `json:"items"`
+}
+
+// batchItemResult is the response of a single item: the resolved
+// object or, on failure, the status.
+type batchItemResult struct {
+	ID     string          `json:"id,omitempty"`
+	Code   int             `json:"code"`
+	Result json.RawMessage `json:"result,omitempty" swaggertype:"object"`
+	Status json.RawMessage `json:"status,omitempty" swaggertype:"object"`
+}
+
+// fail sets the failure status of the item.
+func (r batchItemResult) fail(status *response.Status) batchItemResult {
+	r.Code = status.Code
+	r.Status, _ = json.Marshal(status)
+	return r
+}
+
+// bufferedWriter collects the response of a batch item.
+type bufferedWriter struct {
+	header http.Header
+	code   int
+	buf    bytes.Buffer
+}
+
+var _ http.ResponseWriter = (*bufferedWriter)(nil)
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.buf.Write(p)
+}
+
+func (w *bufferedWriter) statusCode() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
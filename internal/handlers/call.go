@@ -47,13 +47,17 @@ type callHandler struct {
 // @Param  page             query   string  false "Pagination desired page"
 // @Param  perPage          query   string  false "Pagination desired per page items"
 // @Param  extras           query   string  false "JSON encoded map of extra params"
+// @Param  trace            query   bool    false "Include the resolution trace (RESTActions only)"
+// @Param  fixtures         query   string  false "Record or replay the RESTAction HTTP exchanges (record, replay, off); requires --fixtures-override"
 // @Param data body string false "Object"
 // @Produce  json
 // @Success 200 {object} map[string]any
+// @Success 207 {object} map[string]any
 // @Failure 400 {object} response.Status
 // @Failure 401 {object} response.Status
 // @Failure 404 {object} response.Status
 // @Failure 500 {object} response.Status
+// @Failure 502 {object} response.Status
 // @Router /call [get]
 // @Router /call [post]
 // @Router /call [put]
@@ -1,9 +1,13 @@
 package dispatchers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
 	xcontext "github.com/krateoplatformops/plumbing/context"
@@ -12,20 +16,34 @@ import (
 	"github.com/krateoplatformops/snowplow/apis"
 	v1 "github.com/krateoplatformops/snowplow/apis/templates/v1"
 	"github.com/krateoplatformops/snowplow/internal/handlers/util"
+	crdschema "github.com/krateoplatformops/snowplow/internal/resolvers/crds/schema"
 	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/api"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/fixtures"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/params"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/trace"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func RESTAction() http.Handler {
 	return &restActionHandler{
-		authnNS: env.String("AUTHN_NAMESPACE", ""),
-		verbose: env.True("DEBUG"),
+		authnNS:          env.String("AUTHN_NAMESPACE", ""),
+		verbose:          env.True("DEBUG"),
+		fixturesDir:      env.String(fixtures.EnvDir, ""),
+		fixturesMode:     env.String(fixtures.EnvMode, ""),
+		fixturesOverride: env.True(fixtures.EnvOverride),
 	}
 }
 
 type restActionHandler struct {
 	authnNS string
 	verbose bool
+	// fixturesDir holds the recorded fixtures, one file for each RESTAction.
+	fixturesDir string
+	// fixturesMode is the default fixtures mode.
+	fixturesMode string
+	// fixturesOverride allows the fixtures query parameter to override the mode.
+	fixturesOverride bool
 }
 
 var _ http.Handler = (*restActionHandler)(nil)
@@ -69,6 +87,28 @@ func (r *restActionHandler) ServeHTTP(wri http.ResponseWriter, req *http.Request
 	cursor, perPage, page := paginationInfo(log, req)
 
 	ctx := xcontext.BuildContext(req.Context())
+
+	sess, fixturePath, err := r.fixturesSession(ctx, &cr, req.URL.Query().Get("fixtures"))
+	if err != nil {
+		log.Error("unable to setup fixtures",
+			slog.String("name", cr.GetName()),
+			slog.String("namespace", cr.GetNamespace()),
+			slog.Any("err", err))
+		if errors.Is(err, errFixturesOverride) {
+			response.Forbidden(wri, err)
+			return
+		}
+		response.BadRequest(wri, err)
+		return
+	}
+	ctx = fixtures.WithSession(ctx, sess)
+
+	var rec *trace.Recorder
+	if traced, _ := strconv.ParseBool(req.URL.Query().Get("trace")); traced {
+		rec = trace.New()
+		ctx = trace.WithRecorder(ctx, rec)
+	}
+
 	res, err := restactions.Resolve(ctx, restactions.ResolveOptions{
 		In:      &cr,
 		AuthnNS: r.authnNS,
@@ -77,7 +117,69 @@ func (r *restActionHandler) ServeHTTP(wri http.ResponseWriter, req *http.Request
 		Cursor:  cursor,
 		Extras:  extras,
 	})
-	if err != nil {
+	if sess.Recording() && (err == nil || errors.As(err, new(*restactions.StepsError)) ||
+		errors.As(err, new(*crdschema.ViolationsError))) {
+		sess.SetStatus(res.Status)
+		if err := fixtures.Save(fixturePath, sess.Fixture()); err != nil {
+			log.Error("unable to save fixture",
+				slog.String("path", fixturePath), slog.Any("err", err))
+		}
+	}
+
+	var paramsErr *params.Error
+	if errors.As(err, &paramsErr) {
+		log.Warn("invalid rest action params",
+			slog.String("name", cr.GetName()),
+			slog.String("namespace", cr.GetNamespace()),
+			slog.Any("err", err))
+
+		status := response.New(http.StatusBadRequest, err)
+		wri.Header().Set("Content-Type", "application/json")
+		wri.WriteHeader(status.Code)
+		json.NewEncoder(wri).Encode(&invalidParams{
+			Status:        status,
+			InvalidParams: paramsErr.Params,
+		})
+		return
+	}
+
+	var schemaErr *crdschema.ViolationsError
+	if errors.As(err, &schemaErr) {
+		log.Error("rest action status does not match the output schema",
+			slog.String("name", cr.GetName()),
+			slog.String("namespace", cr.GetNamespace()),
+			slog.Any("err", err))
+
+		status := response.New(http.StatusInternalServerError, err)
+		wri.Header().Set("Content-Type", "application/json")
+		wri.WriteHeader(status.Code)
+		json.NewEncoder(wri).Encode(&invalidRESTAction{
+			Status:     status,
+			Violations: schemaErr.Violations,
+		})
+		return
+	}
+
+	var stepsErr *restactions.StepsError
+	if errors.As(err, &stepsErr) {
+		log.Warn("rest action api failure",
+			slog.String("name", cr.GetName()),
+			slog.String("namespace", cr.GetNamespace()),
+			slog.Any("err", err))
+
+		if !stepsErr.Partial {
+			status := response.New(stepsErr.Code(), err)
+			status.Status = response.StatusFailure
+
+			wri.Header().Set("Content-Type", "application/json")
+			wri.WriteHeader(stepsErr.Code())
+			json.NewEncoder(wri).Encode(&failedRESTAction{
+				Status:      status,
+				FailedSteps: stepsErr.Steps,
+			})
+			return
+		}
+	} else if err != nil {
 		log.Error("unable to resolve rest action",
 			slog.String("name", cr.GetName()),
 			slog.String("namespace", cr.GetNamespace()),
@@ -86,15 +188,100 @@ func (r *restActionHandler) ServeHTTP(wri http.ResponseWriter, req *http.Request
 		return
 	}
 
-	log.Info("RESTAction successfully resolved",
-		slog.String("name", cr.Name),
-		slog.String("namespace", cr.Namespace),
-		slog.String("duration", util.ETA(start)),
-	)
+	code := http.StatusOK
+	if stepsErr != nil {
+		code = stepsErr.Code()
+	} else {
+		log.Info("RESTAction successfully resolved",
+			slog.String("name", cr.Name),
+			slog.String("namespace", cr.Namespace),
+			slog.String("duration", util.ETA(start)),
+		)
+	}
+
+	out := &resolvedRESTAction{RESTAction: res}
+	if stepsErr != nil {
+		out.FailedSteps = stepsErr.Steps
+	}
+	if rec != nil {
+		tr := rec.Trace()
+		out.Trace = &tr
+	}
 
 	wri.Header().Set("Content-Type", "application/json")
-	wri.WriteHeader(http.StatusOK)
+	wri.WriteHeader(code)
 	enc := json.NewEncoder(wri)
 	enc.SetIndent("", "  ")
-	enc.Encode(res)
+	enc.Encode(out)
+}
+
+// resolvedRESTAction is the resolved RESTAction with the failed
+// APIs (if any) and the resolution trace (if requested).
+type resolvedRESTAction struct {
+	*v1.RESTAction
+	FailedSteps []api.Outcome `json:"failedSteps,omitempty"`
+	Trace       *trace.Trace  `json:"trace,omitempty"`
+}
+
+// failedRESTAction is the status returned when no API has been resolved.
+type failedRESTAction struct {
+	*response.Status
+	FailedSteps []api.Outcome `json:"failedSteps"`
+}
+
+// invalidParams is the status returned when the extras
+// do not match the declared params.
+type invalidParams struct {
+	*response.Status
+	InvalidParams []params.Invalid `json:"invalidParams"`
+}
+
+// invalidRESTAction is the status returned when the resolved
+// status does not match the declared output schema.
+type invalidRESTAction struct {
+	*response.Status
+	Violations []crdschema.Violation `json:"violations"`
+}
+
+// errFixturesOverride is returned when the fixtures query parameter is not allowed.
+var errFixturesOverride = errors.New("fixtures mode override not enabled")
+
+// fixturesSession returns the session recording or replaying the RESTAction
+// HTTP exchanges of the user, according to the server mode (or to the
+// requested one, if the override is enabled).
+func (r *restActionHandler) fixturesSession(ctx context.Context, cr *v1.RESTAction, requested string) (*fixtures.Session, string, error) {
+	if len(requested) > 0 && !r.fixturesOverride {
+		return nil, "", errFixturesOverride
+	}
+	if len(requested) == 0 {
+		requested = r.fixturesMode
+	}
+
+	mode, err := fixtures.ParseMode(requested)
+	if err != nil || mode == fixtures.ModeOff {
+		return nil, "", err
+	}
+
+	if len(r.fixturesDir) == 0 {
+		return nil, "", fmt.Errorf("fixtures directory not configured")
+	}
+
+	ep, err := xcontext.UserConfig(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	path, err := fixtures.Path(r.fixturesDir, ep.Username, cr.GetNamespace(), cr.GetName())
+	if err != nil {
+		return nil, "", err
+	}
+	if mode == fixtures.ModeRecord {
+		return fixtures.NewRecorder(), path, nil
+	}
+
+	fix, err := fixtures.Load(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to load fixture: %w", err)
+	}
+	return fixtures.NewPlayer(fix), path, nil
 }
@@ -13,6 +13,8 @@ import (
 	"github.com/krateoplatformops/plumbing/http/response"
 	"github.com/krateoplatformops/plumbing/maps"
 	"github.com/krateoplatformops/snowplow/internal/handlers/util"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/params"
 	"github.com/krateoplatformops/snowplow/internal/resolvers/widgets"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
@@ -68,8 +70,18 @@ func (r *widgetsHandler) ServeHTTP(wri http.ResponseWriter, req *http.Request) {
 		Cursor:  cursor,
 		Extras:  extras,
 	})
-	if err != nil {
+	code := http.StatusOK
+	var stepsErr *restactions.StepsError
+	if errors.As(err, &stepsErr) {
+		// the widget is resolved with the partial api data
+		log.Warn("widget api failure", slog.Any("err", err))
+		code = stepsErr.Code()
+	} else if err != nil {
 		log.Error("unable to resolve widget", slog.Any("err", err))
+		if errors.As(err, new(*params.Error)) {
+			response.BadRequest(wri, err)
+			return
+		}
 		var statusErr *apierrors.StatusError
 		if errors.As(err, &statusErr) {
 			code := int(statusErr.Status().Code)
@@ -89,12 +101,14 @@ func (r *widgetsHandler) ServeHTTP(wri http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	log.Info("Widget successfully resolved",
-		slog.String("duration", util.ETA(start)),
-	)
+	if stepsErr == nil {
+		log.Info("Widget successfully resolved",
+			slog.String("duration", util.ETA(start)),
+		)
+	}
 
 	wri.Header().Set("Content-Type", "application/json")
-	wri.WriteHeader(http.StatusOK)
+	wri.WriteHeader(code)
 	enc := json.NewEncoder(wri)
 	enc.SetIndent("", "  ")
 	enc.Encode(res)
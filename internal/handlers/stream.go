@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/env"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
+	"github.com/krateoplatformops/snowplow/internal/handlers/util"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// EnvWidgetsStreamDebounce is the environment variable holding how long
+	// a widget stream waits for further changes before resolving the widget.
+	EnvWidgetsStreamDebounce = "WIDGETS_STREAM_DEBOUNCE"
+
+	defaultWidgetsStreamDebounce = time.Second
+
+	// widgetsStreamMaxWait is the maximum number of debounce intervals a
+	// change waits for: dependencies changing more often than the debounce
+	// interval do not starve the resolution.
+	widgetsStreamMaxWait = 5
+
+	widgetsGroup = "widgets.templates.krateo.io"
+)
+
+// WidgetsStream keeps a widget subscribed: the widget is resolved by the
+// next handler (i.e. the dispatchers) as a `GET /call` request, again and
+// again whenever one of the objects read by the resolution changes.
+func WidgetsStream(next http.Handler) http.Handler {
+	return &widgetsStreamHandler{
+		next:      next,
+		heartbeat: env.Duration(EnvWatchHeartbeat, defaultWatchHeartbeat),
+		debounce:  env.Duration(EnvWidgetsStreamDebounce, defaultWidgetsStreamDebounce),
+	}
+}
+
+var _ http.Handler = (*widgetsStreamHandler)(nil)
+
+type widgetsStreamHandler struct {
+	next      http.Handler
+	heartbeat time.Duration
+	debounce  time.Duration
+}
+
+// @Summary     Widget Stream Endpoint
+// @Description Resolves the widget as `GET /call` does and streams it as Server-Sent Events:
+// @Description the widget is resolved again whenever the widget, its RESTAction (apiRef) or the Kubernetes objects
+// @Description it reads change. Changes are debounced (waiting at most 5 debounce intervals) and a widget equal to the last one sent is not sent again.
+// @Description Each event is a `RESOLVED` widget or, when the resolution fails, a `FAILED` status; idle streams receive a heartbeat comment.
+// @ID widgets-stream
+// @Param  apiVersion  query   string  true  "Widget API Group and Version"
+// @Param  resource    query   string  true  "Widget Resource Plural"
+// @Param  name        query   string  true  "Widget name"
+// @Param  namespace   query   string  true  "Widget namespace"
+// @Param  page        query   int     false "Page number"
+// @Param  perPage     query   int     false "Number of items per page"
+// @Param  extras      query   string  false "Extra parameters (JSON object)"
+// @Produce  text/event-stream
+// @Success 200 {string} string "Stream of resolved widgets"
+// @Failure 400 {object} response.Status
+// @Failure 401 {object} response.Status
+// @Failure 403 {object} response.Status
+// @Failure 404 {object} response.Status
+// @Failure 500 {object} response.Status
+// @Router /widgets/stream [get]
+func (r *widgetsStreamHandler) ServeHTTP(wri http.ResponseWriter, req *http.Request) {
+	log := xcontext.Logger(req.Context())
+
+	gvr, err := util.ParseGVR(req)
+	if err != nil {
+		response.BadRequest(wri, err)
+		return
+	}
+	if gvr.Group != widgetsGroup {
+		response.BadRequest(wri, fmt.Errorf("'%s' is not a widget resource", gvr.String()))
+		return
+	}
+	if _, err := util.ParseNamespacedName(req); err != nil {
+		response.BadRequest(wri, err)
+		return
+	}
+
+	if _, err := xcontext.UserConfig(req.Context()); err != nil {
+		log.Error("unable to get user endpoint", slog.Any("err", err))
+		response.Unauthorized(wri, err)
+		return
+	}
+
+	// the resolutions share the user clients and the discovery cache
+	ctx := dynamic.WithCache(req.Context(), dynamic.NewCache())
+	req = req.WithContext(ctx)
+
+	got := r.resolve(req)
+	if got.failed {
+		wri.Header().Set("Content-Type", "application/json")
+		wri.WriteHeader(got.code)
+		wri.Write(got.data)
+		return
+	}
+
+	ctl, err := startEventStream(wri)
+	if err != nil {
+		log.Error("streaming not supported", slog.Any("err", err))
+		return
+	}
+
+	seq := 0
+	send := func(el widgetResolution) error {
+		seq++
+		event := "RESOLVED"
+		if el.failed {
+			event = "FAILED"
+		}
+		if _, err := fmt.Fprintf(wri, "id: %d\nevent: %s\ndata: %s\n\n", seq, event, el.data); err != nil {
+			return err
+		}
+		return ctl.Flush()
+	}
+
+	if err := send(got); err != nil {
+		return
+	}
+	last := got.data
+
+	changes := make(chan struct{}, 1)
+	stop := r.watch(ctx, got.reads, changes)
+	defer func() { stop() }()
+
+	log.Info("widget stream started", slog.String("gvr", gvr.String()),
+		slog.Int("dependencies", len(got.reads.WatchOptions())))
+
+	heartbeat := time.NewTicker(max(r.heartbeat, time.Second))
+	defer heartbeat.Stop()
+
+	debounce := time.NewTimer(r.debounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	// first is when the first change not yet resolved has been notified
+	var first time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("widget stream closed by client", slog.String("gvr", gvr.String()))
+			return
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(wri, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := ctl.Flush(); err != nil {
+				return
+			}
+
+		case <-changes:
+			if first.IsZero() {
+				first = time.Now()
+			}
+			// wait for the changes to settle, but not longer than the max wait
+			wait := min(r.debounce, time.Until(first.Add(widgetsStreamMaxWait*r.debounce)))
+			debounce.Reset(max(wait, 0))
+
+		case <-debounce.C:
+			first = time.Time{}
+			stop()
+
+			got := r.resolve(req)
+			// the dependencies may be changed (i.e. a different apiRef)
+			stop = r.watch(ctx, got.reads, changes)
+
+			if bytes.Equal(got.data, last) {
+				log.Debug("widget unchanged", slog.String("gvr", gvr.String()))
+				continue
+			}
+			last = got.data
+
+			if err := send(got); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// widgetResolution is the outcome of a widget resolution.
+type widgetResolution struct {
+	code int
+	// data is the compact JSON of the widget (or of the failure status).
+	data   []byte
+	failed bool
+	// reads are the objects read by the resolution.
+	reads *dynamic.Reads
+}
+
+// resolve serves the widget as a `GET /call` request, recording the read objects.
+func (r *widgetsStreamHandler) resolve(req *http.Request) (res widgetResolution) {
+	res.reads = dynamic.NewReads()
+
+	fail := func(status *response.Status) widgetResolution {
+		res.code, res.failed = status.Code, true
+		res.data, _ = json.Marshal(status)
+		return res
+	}
+
+	ctx := dynamic.WithReads(req.Context(), res.reads)
+	sub, err := http.NewRequestWithContext(ctx, http.MethodGet, "/call?"+req.URL.RawQuery, nil)
+	if err != nil {
+		return fail(response.New(http.StatusInternalServerError, err))
+	}
+	sub.Header = req.Header.Clone()
+
+	rec := &bufferedWriter{header: http.Header{}}
+	r.next.ServeHTTP(rec, sub)
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, rec.buf.Bytes()); err != nil {
+		return fail(response.New(http.StatusInternalServerError,
+			fmt.Errorf("invalid response: %s", rec.buf.String())))
+	}
+
+	res.code = rec.statusCode()
+	res.data = buf.Bytes()
+	res.failed = isStatus(res.data)
+	return res
+}
+
+// watch watches the changes of the read objects, notifying them on the
+// changes channel; the returned function stops the watches.
+// A failed or closed watch is notified too: the resolution restarts it.
+func (r *widgetsStreamHandler) watch(ctx context.Context, reads *dynamic.Reads, changes chan<- struct{}) (stop func()) {
+	log := xcontext.Logger(ctx)
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	stop = func() {
+		cancel()
+		wg.Wait()
+	}
+
+	ep, err := xcontext.UserConfig(ctx)
+	if err != nil {
+		log.Error("unable to get user endpoint", slog.Any("err", err))
+		return
+	}
+
+	rc, err := dynamic.ClientConfig(ctx, ep)
+	if err != nil {
+		log.Error("unable to create user client config", slog.Any("err", err))
+		return
+	}
+
+	cli, err := dynamic.ClientFor(ctx, rc)
+	if err != nil {
+		log.Error("cannot create dynamic client", slog.Any("err", err))
+		return
+	}
+
+	notify := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+
+	for _, opts := range reads.WatchOptions() {
+		w, err := cli.Watch(ctx, opts)
+		if err != nil {
+			// i.e. the user can read but not watch the objects
+			log.Warn("unable to watch widget dependency",
+				slog.String("gvr", opts.GVR.String()), slog.String("namespace", opts.Namespace),
+				slog.Any("err", err))
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer w.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case ev, ok := <-w.ResultChan():
+					if ok && ev.Type == watch.Bookmark {
+						continue
+					}
+					notify()
+					if !ok || ev.Type == watch.Error {
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	return
+}
@@ -0,0 +1,130 @@
+//go:build unit
+// +build unit
+
+// The handlers tests not needing the kind cluster set up by the TestMain
+// of the handlers package.
+package unit_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/endpoints"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
+	"github.com/krateoplatformops/snowplow/internal/handlers"
+)
+
+func TestBatch(t *testing.T) {
+	var caches = make(chan *dynamic.Cache, 3)
+
+	next := http.HandlerFunc(func(wri http.ResponseWriter, req *http.Request) {
+		caches <- dynamic.CacheFromContext(req.Context())
+
+		q := req.URL.Query()
+		if q.Get("name") == "missing" {
+			response.NotFound(wri, fmt.Errorf("%s not found", q.Get("name")))
+			return
+		}
+
+		wri.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(wri).Encode(map[string]any{
+			"name": q.Get("name"), "perPage": q.Get("perPage"), "extras": q.Get("extras"),
+		})
+	})
+
+	body := `{"items": [
+		{"id": "a", "apiVersion": "templates.krateo.io/v1", "resource": "restactions", "name": "one", "namespace": "demo", "perPage": 5},
+		{"id": "b", "apiVersion": "templates.krateo.io/v1", "resource": "restactions", "name": "missing", "namespace": "demo"},
+		{"apiVersion": "widgets.templates.krateo.io/v1beta1", "resource": "tables", "name": "two", "namespace": "demo", "extras": {"q": "x"}}
+	]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(xcontext.BuildContext(context.Background(),
+		xcontext.WithLogger(slog.New(slog.DiscardHandler)),
+		xcontext.WithUserConfig(endpoints.Endpoint{ServerURL: "https://127.0.0.1:6443"}),
+	))
+
+	rec := httptest.NewRecorder()
+	handlers.Batch(next).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var got struct {
+		Items []struct {
+			ID     string         `json:"id"`
+			Code   int            `json:"code"`
+			Result map[string]any `json:"result"`
+			Status map[string]any `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(got.Items))
+	}
+
+	if el := got.Items[0]; el.ID != "a" || el.Code != http.StatusOK ||
+		el.Result["name"] != "one" || el.Result["perPage"] != "5" || el.Status != nil {
+		t.Errorf("unexpected first item: %+v", el)
+	}
+	if el := got.Items[1]; el.ID != "b" || el.Code != http.StatusNotFound ||
+		el.Result != nil || el.Status["reason"] != "NotFound" {
+		t.Errorf("unexpected second item: %+v", el)
+	}
+	if el := got.Items[2]; el.Code != http.StatusOK || el.Result["extras"] != `{"q":"x"}` {
+		t.Errorf("unexpected third item: %+v", el)
+	}
+
+	close(caches)
+	var shared *dynamic.Cache
+	for c := range caches {
+		if c == nil || (shared != nil && c != shared) {
+			t.Fatalf("expected the items to share the same cache")
+		}
+		shared = c
+	}
+}
+
+func TestBatchInvalid(t *testing.T) {
+	ctx := xcontext.BuildContext(context.Background(),
+		xcontext.WithLogger(slog.New(slog.DiscardHandler)),
+		xcontext.WithUserConfig(endpoints.Endpoint{ServerURL: "https://127.0.0.1:6443"}),
+	)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		code        int
+	}{
+		{name: "content type", contentType: "text/plain", body: `{}`, code: http.StatusNotAcceptable},
+		{name: "malformed", contentType: "application/json", body: `{"items": [`, code: http.StatusBadRequest},
+		{name: "no items", contentType: "application/json", body: `{"items": []}`, code: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			req = req.WithContext(ctx)
+
+			rec := httptest.NewRecorder()
+			handlers.Batch(http.NotFoundHandler()).ServeHTTP(rec, req)
+			if rec.Code != tc.code {
+				t.Errorf("expected status %d, got %d", tc.code, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,518 @@
+//go:build unit
+// +build unit
+
+package unit_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/endpoints"
+	"github.com/krateoplatformops/plumbing/env"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/plumbing/jwtutil"
+	templatesv1 "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
+	"github.com/krateoplatformops/snowplow/internal/handlers"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/widgets/apiref"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// fakeConfigMapServer serves the config map read by the widget: the first
+// watch reports a change that leaves the data as it is, the second one
+// changes the data.
+func fakeConfigMapServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	// keep the user endpoint server URL
+	env.SetTestMode(true)
+
+	var (
+		mu      sync.Mutex
+		rv      = 1
+		image   = "nginx:1"
+		watches = 0
+	)
+
+	configMap := func() string {
+		return fmt.Sprintf(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"nginx","namespace":"demo","resourceVersion":"%d"},"data":{"image":%q}}`, rv, image)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIVersions","versions":["v1"]}`)
+	})
+	mux.HandleFunc("GET /apis", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIGroupList","apiVersion":"v1","groups":[]}`)
+	})
+	mux.HandleFunc("GET /api/v1", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIResourceList","groupVersion":"v1","resources":[
+			{"name":"configmaps","singularName":"configmap","namespaced":true,"kind":"ConfigMap","verbs":["get","list","watch"]}]}`)
+	})
+	mux.HandleFunc("GET /api/v1/namespaces/demo/configmaps/nginx", func(wri http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, configMap())
+	})
+	mux.HandleFunc("GET /api/v1/namespaces/demo/configmaps", func(wri http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		if q.Get("watch") != "true" || q.Get("fieldSelector") != "metadata.name=nginx" {
+			t.Errorf("unexpected watch query: %s", req.URL.RawQuery)
+		}
+
+		mu.Lock()
+		if q.Get("resourceVersion") != fmt.Sprint(rv) {
+			t.Errorf("expected watch from resource version %d, got: %s", rv, req.URL.RawQuery)
+		}
+		watches++
+		switch watches {
+		case 1:
+			rv++
+		case 2:
+			rv, image = rv+1, "nginx:2"
+		}
+		ev := fmt.Sprintf(`{"type":"MODIFIED","object":%s}`+"\n", configMap())
+		n := watches
+		mu.Unlock()
+
+		wri.Header().Set("Content-Type", "application/json")
+		wri.WriteHeader(http.StatusOK)
+		if n <= 2 {
+			fmt.Fprint(wri, ev)
+		}
+		http.NewResponseController(wri).Flush()
+
+		<-req.Context().Done()
+	})
+
+	return httptest.NewServer(mux)
+}
+
+// widgetStub resolves the widget reading the config map.
+func widgetStub() http.Handler {
+	return http.HandlerFunc(func(wri http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("name") == "missing" {
+			response.NotFound(wri, fmt.Errorf("widget not found"))
+			return
+		}
+
+		ep, _ := xcontext.UserConfig(req.Context())
+		rc, err := dynamic.ClientConfig(req.Context(), ep)
+		if err != nil {
+			response.InternalError(wri, err)
+			return
+		}
+		cli, err := dynamic.ClientFor(req.Context(), rc)
+		if err != nil {
+			response.InternalError(wri, err)
+			return
+		}
+
+		got, err := cli.Get(req.Context(), "nginx", dynamic.Options{
+			Namespace: "demo", GVR: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+		})
+		if err != nil {
+			response.InternalError(wri, err)
+			return
+		}
+
+		wri.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(wri).Encode(map[string]any{
+			"kind": "Table", "status": got.Object["data"],
+		})
+	})
+}
+
+func TestWidgetsStream(t *testing.T) {
+	api := fakeConfigMapServer(t)
+	defer api.Close()
+
+	t.Setenv(handlers.EnvWidgetsStreamDebounce, "10ms")
+
+	srv := httptest.NewServer(withUser(api.URL, handlers.WidgetsStream(widgetStub())))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet,
+		srv.URL+"/widgets/stream?apiVersion=widgets.templates.krateo.io/v1beta1&resource=tables&name=pods&namespace=demo", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.StatusCode)
+	}
+
+	var got []string
+	sc := bufio.NewScanner(res.Body)
+	for len(got) < 8 && sc.Scan() {
+		if line := sc.Text(); !strings.HasPrefix(line, ":") {
+			got = append(got, line)
+		}
+	}
+
+	// the first change leaves the widget as it is
+	want := []string{
+		"id: 1", "event: RESOLVED", `data: {"kind":"Table","status":{"image":"nginx:1"}}`, "",
+		"id: 2", "event: RESOLVED", `data: {"kind":"Table","status":{"image":"nginx:2"}}`, "",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected stream:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+// busyConfigMapServer serves the config map read by the widget: its watches
+// report a change every few milliseconds, the first one changing the data.
+func busyConfigMapServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	// keep the user endpoint server URL
+	env.SetTestMode(true)
+
+	var (
+		mu    sync.Mutex
+		rv    = 1
+		image = "nginx:1"
+	)
+
+	configMap := func() string {
+		return fmt.Sprintf(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"nginx","namespace":"demo","resourceVersion":"%d"},"data":{"image":%q}}`, rv, image)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIVersions","versions":["v1"]}`)
+	})
+	mux.HandleFunc("GET /apis", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIGroupList","apiVersion":"v1","groups":[]}`)
+	})
+	mux.HandleFunc("GET /api/v1", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIResourceList","groupVersion":"v1","resources":[
+			{"name":"configmaps","singularName":"configmap","namespaced":true,"kind":"ConfigMap","verbs":["get","list","watch"]}]}`)
+	})
+	mux.HandleFunc("GET /api/v1/namespaces/demo/configmaps/nginx", func(wri http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, configMap())
+	})
+	mux.HandleFunc("GET /api/v1/namespaces/demo/configmaps", func(wri http.ResponseWriter, req *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		wri.WriteHeader(http.StatusOK)
+
+		tick := time.NewTicker(5 * time.Millisecond)
+		defer tick.Stop()
+		for {
+			select {
+			case <-req.Context().Done():
+				return
+			case <-tick.C:
+			}
+
+			mu.Lock()
+			rv, image = rv+1, "nginx:2"
+			ev := fmt.Sprintf(`{"type":"MODIFIED","object":%s}`+"\n", configMap())
+			mu.Unlock()
+
+			fmt.Fprint(wri, ev)
+			http.NewResponseController(wri).Flush()
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestWidgetsStreamBusyDependency(t *testing.T) {
+	api := busyConfigMapServer(t)
+	defer api.Close()
+
+	// the changes never settle: the widget is resolved after the max wait
+	t.Setenv(handlers.EnvWidgetsStreamDebounce, "50ms")
+
+	srv := httptest.NewServer(withUser(api.URL, handlers.WidgetsStream(widgetStub())))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet,
+		srv.URL+"/widgets/stream?apiVersion=widgets.templates.krateo.io/v1beta1&resource=tables&name=pods&namespace=demo", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var got []string
+	sc := bufio.NewScanner(res.Body)
+	for len(got) < 8 && sc.Scan() {
+		if line := sc.Text(); !strings.HasPrefix(line, ":") {
+			got = append(got, line)
+		}
+	}
+
+	want := []string{
+		"id: 1", "event: RESOLVED", `data: {"kind":"Table","status":{"image":"nginx:1"}}`, "",
+		"id: 2", "event: RESOLVED", `data: {"kind":"Table","status":{"image":"nginx:2"}}`, "",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected stream:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+// fakeRESTActionServer serves the RESTAction read by the widget (apiRef) and
+// the config map read by its API through the internal endpoint: the first
+// watch of the RESTAction changes its filter, the second watch of the
+// config map changes the data.
+func fakeRESTActionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	// keep the user endpoint server URL
+	env.SetTestMode(true)
+
+	var (
+		mu      sync.Mutex
+		raRV    = 1
+		filter  = "{image: .cm}"
+		cmRV    = 1
+		image   = "nginx:1"
+		watches = map[string]int{}
+		srv     *httptest.Server
+	)
+
+	restAction := func() string {
+		return fmt.Sprintf(`{"apiVersion":"templates.krateo.io/v1","kind":"RESTAction",`+
+			`"metadata":{"name":"pods","namespace":"demo","resourceVersion":"%d"},`+
+			`"spec":{"api":[{"name":"cm","path":"/api/v1/namespaces/demo/configmaps/nginx","filter":".cm.data.image"}],"filter":%q}}`,
+			raRV, filter)
+	}
+	configMap := func() string {
+		return fmt.Sprintf(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"nginx","namespace":"demo","resourceVersion":"%d"},"data":{"image":%q}}`, cmRV, image)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIVersions","versions":["v1"]}`)
+	})
+	mux.HandleFunc("GET /apis", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIGroupList","apiVersion":"v1","groups":[{"name":"templates.krateo.io",
+			"versions":[{"groupVersion":"templates.krateo.io/v1","version":"v1"}],
+			"preferredVersion":{"groupVersion":"templates.krateo.io/v1","version":"v1"}}]}`)
+	})
+	mux.HandleFunc("GET /api/v1", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIResourceList","groupVersion":"v1","resources":[
+			{"name":"configmaps","singularName":"configmap","namespaced":true,"kind":"ConfigMap","verbs":["get","list","watch"]}]}`)
+	})
+	mux.HandleFunc("GET /apis/templates.krateo.io/v1", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIResourceList","groupVersion":"templates.krateo.io/v1","resources":[
+			{"name":"restactions","singularName":"restaction","namespaced":true,"kind":"RESTAction","verbs":["get","list","watch"]}]}`)
+	})
+	// the internal endpoint of the user
+	mux.HandleFunc("GET /api/v1/namespaces/krateo-system/secrets/cyberjoker-clientconfig", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(wri, `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"cyberjoker-clientconfig","namespace":"krateo-system"},"data":{"server-url":%q,"token":%q}}`,
+			base64.StdEncoding.EncodeToString([]byte(srv.URL)), base64.StdEncoding.EncodeToString([]byte("token")))
+	})
+	mux.HandleFunc("GET /apis/templates.krateo.io/v1/namespaces/demo/restactions/pods", func(wri http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, restAction())
+	})
+	mux.HandleFunc("GET /api/v1/namespaces/demo/configmaps/nginx", func(wri http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, configMap())
+	})
+
+	watch := func(name string, rv *int, change func() string) http.HandlerFunc {
+		return func(wri http.ResponseWriter, req *http.Request) {
+			q := req.URL.Query()
+			if q.Get("watch") != "true" || q.Get("fieldSelector") != "metadata.name="+name {
+				t.Errorf("unexpected watch query: %s", req.URL.RawQuery)
+			}
+
+			mu.Lock()
+			if q.Get("resourceVersion") != fmt.Sprint(*rv) {
+				t.Errorf("expected watch from resource version %d, got: %s", *rv, req.URL.RawQuery)
+			}
+			watches[req.URL.Path]++
+			ev := change()
+			mu.Unlock()
+
+			wri.Header().Set("Content-Type", "application/json")
+			wri.WriteHeader(http.StatusOK)
+			if len(ev) > 0 {
+				fmt.Fprintf(wri, `{"type":"MODIFIED","object":%s}`+"\n", ev)
+			}
+			http.NewResponseController(wri).Flush()
+
+			<-req.Context().Done()
+		}
+	}
+
+	const (
+		restActions = "/apis/templates.krateo.io/v1/namespaces/demo/restactions"
+		configMaps  = "/api/v1/namespaces/demo/configmaps"
+	)
+	mux.HandleFunc("GET "+restActions, watch("pods", &raRV, func() string {
+		if watches[restActions] != 1 {
+			return ""
+		}
+		raRV, filter = raRV+1, "{image: .cm, source: \"restaction\"}"
+		return restAction()
+	}))
+	mux.HandleFunc("GET "+configMaps, watch("nginx", &cmRV, func() string {
+		if watches[configMaps] != 2 {
+			return ""
+		}
+		cmRV, image = cmRV+1, "nginx:2"
+		return configMap()
+	}))
+
+	srv = httptest.NewServer(mux)
+	return srv
+}
+
+// restActionStub resolves the widget as the widgets resolver does:
+// its apiRef RESTAction is read and resolved.
+func restActionStub(rc *rest.Config) http.Handler {
+	return http.HandlerFunc(func(wri http.ResponseWriter, req *http.Request) {
+		got, err := apiref.Resolve(req.Context(), apiref.ResolveOptions{
+			RC: rc, AuthnNS: "krateo-system",
+			ApiRef: templatesv1.ObjectReference{
+				Reference:  templatesv1.Reference{Name: "pods", Namespace: "demo"},
+				APIVersion: "templates.krateo.io/v1", Resource: "restactions",
+			},
+		})
+		if err != nil {
+			response.InternalError(wri, err)
+			return
+		}
+
+		wri.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(wri).Encode(map[string]any{
+			"kind": "Table", "status": got,
+		})
+	})
+}
+
+func TestWidgetsStreamRESTAction(t *testing.T) {
+	api := fakeRESTActionServer(t)
+	defer api.Close()
+
+	t.Setenv(handlers.EnvWidgetsStreamDebounce, "10ms")
+
+	next := restActionStub(&rest.Config{Host: api.URL})
+	srv := httptest.NewServer(withUser(api.URL, handlers.WidgetsStream(next)))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet,
+		srv.URL+"/widgets/stream?apiVersion=widgets.templates.krateo.io/v1beta1&resource=tables&name=pods&namespace=demo", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.StatusCode)
+	}
+
+	var got []string
+	sc := bufio.NewScanner(res.Body)
+	for len(got) < 12 && sc.Scan() {
+		if line := sc.Text(); !strings.HasPrefix(line, ":") {
+			got = append(got, line)
+		}
+	}
+
+	// the RESTAction changes first, then the config map read by its API
+	want := []string{
+		"id: 1", "event: RESOLVED", `data: {"kind":"Table","status":{"image":"nginx:1"}}`, "",
+		"id: 2", "event: RESOLVED", `data: {"kind":"Table","status":{"image":"nginx:1","source":"restaction"}}`, "",
+		"id: 3", "event: RESOLVED", `data: {"kind":"Table","status":{"image":"nginx:2","source":"restaction"}}`, "",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected stream:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestWidgetsStreamFailure(t *testing.T) {
+	api := fakeConfigMapServer(t)
+	defer api.Close()
+
+	srv := httptest.NewServer(withUser(api.URL, handlers.WidgetsStream(widgetStub())))
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+	}{
+		{name: "not a widget", query: "apiVersion=templates.krateo.io/v1&resource=restactions&name=pods&namespace=demo", code: http.StatusBadRequest},
+		{name: "missing name", query: "apiVersion=widgets.templates.krateo.io/v1beta1&resource=tables&namespace=demo", code: http.StatusBadRequest},
+		{name: "not found", query: "apiVersion=widgets.templates.krateo.io/v1beta1&resource=tables&name=missing&namespace=demo", code: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := http.Get(srv.URL + "/widgets/stream?" + tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tc.code {
+				t.Errorf("expected status %d, got %d", tc.code, res.StatusCode)
+			}
+		})
+	}
+}
+
+// withUser serves the requests with the user endpoint of the API server.
+func withUser(serverURL string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(wri http.ResponseWriter, req *http.Request) {
+		ctx := xcontext.BuildContext(req.Context(),
+			xcontext.WithLogger(slog.New(slog.DiscardHandler)),
+			xcontext.WithUserConfig(endpoints.Endpoint{ServerURL: serverURL, Username: "cyberjoker", Token: "token"}),
+			xcontext.WithUserInfo(jwtutil.UserInfo{Username: "cyberjoker"}),
+		)
+		next.ServeHTTP(wri, req.WithContext(ctx))
+	})
+}
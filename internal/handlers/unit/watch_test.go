@@ -0,0 +1,151 @@
+//go:build unit
+// +build unit
+
+package unit_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/endpoints"
+	"github.com/krateoplatformops/plumbing/env"
+	"github.com/krateoplatformops/snowplow/internal/handlers"
+)
+
+// fakeAPIServer serves the discovery of the pods resource and a watch
+// stream of the pods in the demo namespace.
+func fakeAPIServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	// keep the user endpoint server URL
+	env.SetTestMode(true)
+
+	pod := func(rv string) string {
+		return fmt.Sprintf(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"nginx","namespace":"demo","resourceVersion":%q}}`, rv)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIVersions","versions":["v1"]}`)
+	})
+	mux.HandleFunc("GET /apis", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIGroupList","apiVersion":"v1","groups":[]}`)
+	})
+	mux.HandleFunc("GET /api/v1", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, `{"kind":"APIResourceList","groupVersion":"v1","resources":[
+			{"name":"pods","singularName":"pod","namespaced":true,"kind":"Pod","verbs":["get","list","watch"]}]}`)
+	})
+	mux.HandleFunc("GET /api/v1/namespaces/{ns}/pods", func(wri http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		if req.PathValue("ns") != "demo" {
+			wri.Header().Set("Content-Type", "application/json")
+			wri.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(wri, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403,"message":"pods is forbidden"}`)
+			return
+		}
+		if q.Get("watch") != "true" || q.Get("fieldSelector") != "metadata.name=nginx" ||
+			q.Get("labelSelector") != "app=web" || q.Get("resourceVersion") != "7" {
+			t.Errorf("unexpected watch query: %s", req.URL.RawQuery)
+		}
+
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(wri, `{"type":"ADDED","object":%s}`+"\n", pod("10"))
+		fmt.Fprintf(wri, `{"type":"BOOKMARK","object":%s}`+"\n", pod("11"))
+		fmt.Fprintf(wri, `{"type":"MODIFIED","object":%s}`+"\n", pod("12"))
+		fmt.Fprint(wri, `{"type":"ERROR","object":{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Expired","code":410,"message":"too old resource version"}}`+"\n")
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestWatch(t *testing.T) {
+	srv := fakeAPIServer(t)
+	defer srv.Close()
+
+	ctx := xcontext.BuildContext(context.Background(),
+		xcontext.WithLogger(slog.New(slog.DiscardHandler)),
+		xcontext.WithUserConfig(endpoints.Endpoint{ServerURL: srv.URL, Username: "cyberjoker", Token: "token"}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/watch?apiVersion=v1&resource=pods&namespace=demo&name=nginx&labelSelector=app%3Dweb&resourceVersion=3", nil)
+	req.Header.Set("Last-Event-ID", "7")
+	req = req.WithContext(ctx)
+
+	rec := httptest.NewRecorder()
+	handlers.Watch().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	body := rec.Body.String()
+
+	var got []string
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "data: ") {
+			line = line[:min(len(line), 12)]
+		}
+		got = append(got, line)
+	}
+
+	want := []string{
+		"id: 10", "event: ADDED", `data: {"apiV`, "",
+		"id: 11", "",
+		"id: 12", "event: MODIFIED", `data: {"apiV`, "",
+		"event: ERROR", `data: {"kind`, "",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected stream:\n%s", body)
+	}
+	if !strings.Contains(body, `"code":410`) {
+		t.Fatalf("expected the error status, got:\n%s", body)
+	}
+}
+
+func TestWatchFailure(t *testing.T) {
+	srv := fakeAPIServer(t)
+	defer srv.Close()
+
+	ctx := xcontext.BuildContext(context.Background(),
+		xcontext.WithLogger(slog.New(slog.DiscardHandler)),
+		xcontext.WithUserConfig(endpoints.Endpoint{ServerURL: srv.URL, Username: "cyberjoker", Token: "token"}),
+	)
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+	}{
+		{name: "missing resource", query: "apiVersion=v1", code: http.StatusBadRequest},
+		{name: "unknown resource", query: "apiVersion=v1&resource=widgets", code: http.StatusNotFound},
+		{name: "forbidden", query: "apiVersion=v1&resource=pods&namespace=secret", code: http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/watch?"+tc.query, nil).WithContext(ctx)
+
+			rec := httptest.NewRecorder()
+			handlers.Watch().ServeHTTP(rec, req)
+			if rec.Code != tc.code {
+				t.Errorf("expected status %d, got %d: %s", tc.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/http/response"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/validate"
+	"sigs.k8s.io/yaml"
+)
+
+// @Summary     Validate a RESTAction
+// @Description This endpoint accepts a RESTAction (JSON or YAML) and statically checks it:
+// @Description the APIs dependency graph, all the JQ expressions and the referenced endpoint secrets (readable by the user).
+// @Description It returns the list of diagnostics, each one with the path of the offending field.
+// @Tags        restactions
+// @Accept      json
+// @Accept      application/yaml
+// @Produce     json
+// @Param       body  body   v1.RESTAction  true  "RESTAction to validate"
+// @Success     200   {object}  validateout  "Validation result"
+// @Failure 400 {object} response.Status
+// @Failure 401 {object} response.Status
+// @Failure 406 {object} response.Status
+// @Router      /restactions/validate [post]
+func Validate() http.HandlerFunc {
+	return func(wri http.ResponseWriter, req *http.Request) {
+		log := xcontext.Logger(req.Context())
+
+		ep, err := xcontext.UserConfig(req.Context())
+		if err != nil {
+			log.Error("unable to get user endpoint", slog.Any("err", err))
+			response.Unauthorized(wri, err)
+			return
+		}
+
+		contentType := req.Header.Get("Content-Type")
+		if !strings.Contains(contentType, "json") && !strings.Contains(contentType, "yaml") {
+			err := fmt.Errorf("unsupported content type '%s' use 'application/json' or 'application/yaml'", contentType)
+			log.Error(err.Error())
+			response.NotAcceptable(wri, err)
+			return
+		}
+
+		dat, err := io.ReadAll(io.LimitReader(req.Body, MaxBodySize))
+		if err != nil {
+			log.Error("unable to read request body", slog.Any("err", err))
+			response.BadRequest(wri, err)
+			return
+		}
+
+		in := &templates.RESTAction{}
+		if err := yaml.Unmarshal(dat, in); err != nil {
+			log.Error("unable to decode RESTAction", slog.Any("err", err))
+			response.BadRequest(wri, err)
+			return
+		}
+
+		// the secrets are checked with the user credentials, as /call does
+		rc, err := dynamic.ClientConfig(req.Context(), ep)
+		if err != nil {
+			log.Warn("unable to create user client config, endpoint secrets will not be checked", slog.Any("err", err))
+			rc = nil
+		}
+
+		all := validate.Validate(req.Context(), in, validate.Options{RC: rc})
+		if all == nil {
+			all = []validate.Diagnostic{}
+		}
+
+		wri.Header().Set("Content-Type", "application/json")
+		wri.WriteHeader(http.StatusOK)
+		enc := json.NewEncoder(wri)
+		enc.SetIndent("", "  ")
+		enc.Encode(&validateout{
+			Valid:       validate.Valid(all),
+			Diagnostics: all,
+		})
+	}
+}
+
+type validateout struct {
+	Valid       bool                  `json:"valid"`
+	Diagnostics []validate.Diagnostic `json:"diagnostics"`
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/env"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
+	"github.com/krateoplatformops/snowplow/internal/handlers/util"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// EnvWatchHeartbeat is the environment variable holding the interval
+	// between the heartbeats sent on idle watch streams.
+	EnvWatchHeartbeat = "WATCH_HEARTBEAT"
+
+	defaultWatchHeartbeat = 30 * time.Second
+)
+
+func Watch() http.Handler {
+	return &watchHandler{
+		heartbeat: env.Duration(EnvWatchHeartbeat, defaultWatchHeartbeat),
+	}
+}
+
+var _ http.Handler = (*watchHandler)(nil)
+
+type watchHandler struct {
+	heartbeat time.Duration
+}
+
+// @Summary     Watch Endpoint
+// @Description Streams the changes (ADDED, MODIFIED, DELETED) of the selected objects as Server-Sent Events,
+// @Description using the user credentials. The id of each event is the object resourceVersion:
+// @Description the stream can be resumed from it with the `Last-Event-ID` header or the `resourceVersion` parameter.
+// @Description Idle streams receive a heartbeat comment; an `ERROR` event (i.e. resourceVersion too old) ends the stream.
+// @ID watch
+// @Param  apiVersion       query   string  true  "Resource API Group and Version"
+// @Param  resource         query   string  true  "Resource Plural"
+// @Param  namespace        query   string  false "Resource namespace (all namespaces if omitted)"
+// @Param  name             query   string  false "Resource name (all objects if omitted)"
+// @Param  labelSelector    query   string  false "Label selector restricting the watched objects"
+// @Param  resourceVersion  query   string  false "Resource version from which the changes are streamed"
+// @Param  Last-Event-ID    header  string  false "Id of the last received event (overrides resourceVersion)"
+// @Produce  text/event-stream
+// @Success 200 {string} string "Stream of events"
+// @Failure 400 {object} response.Status
+// @Failure 401 {object} response.Status
+// @Failure 403 {object} response.Status
+// @Failure 404 {object} response.Status
+// @Failure 500 {object} response.Status
+// @Router /watch [get]
+func (r *watchHandler) ServeHTTP(wri http.ResponseWriter, req *http.Request) {
+	log := xcontext.Logger(req.Context())
+
+	opts, err := watchOptions(req)
+	if err != nil {
+		response.BadRequest(wri, err)
+		return
+	}
+
+	ep, err := xcontext.UserConfig(req.Context())
+	if err != nil {
+		log.Error("unable to get user endpoint", slog.Any("err", err))
+		response.Unauthorized(wri, err)
+		return
+	}
+
+	rc, err := dynamic.ClientConfig(req.Context(), ep)
+	if err != nil {
+		log.Error("unable to create user client config", slog.Any("err", err))
+		response.InternalError(wri, err)
+		return
+	}
+
+	cli, err := dynamic.ClientFor(req.Context(), rc)
+	if err != nil {
+		log.Error("cannot create dynamic client", slog.Any("err", err))
+		response.InternalError(wri, err)
+		return
+	}
+
+	w, err := cli.Watch(req.Context(), opts)
+	if err != nil {
+		log.Error("unable to watch resources",
+			slog.String("gvr", opts.GVR.String()), slog.String("namespace", opts.Namespace),
+			slog.Any("err", err))
+		response.Encode(wri, watchFailure(err))
+		return
+	}
+	defer w.Stop()
+
+	ctl, err := startEventStream(wri)
+	if err != nil {
+		log.Error("streaming not supported", slog.Any("err", err))
+		return
+	}
+
+	log.Info("watch started",
+		slog.String("gvr", opts.GVR.String()), slog.String("namespace", opts.Namespace),
+		slog.String("resourceVersion", opts.ResourceVersion))
+
+	heartbeat := time.NewTicker(max(r.heartbeat, time.Second))
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			log.Info("watch closed by client", slog.String("gvr", opts.GVR.String()))
+			return
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(wri, ": heartbeat\n\n"); err != nil {
+				return
+			}
+
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				log.Info("watch closed by server", slog.String("gvr", opts.GVR.String()))
+				return
+			}
+
+			done, err := writeEvent(wri, ev)
+			if err != nil {
+				log.Error("unable to write watch event", slog.Any("err", err))
+				return
+			}
+			if done {
+				return
+			}
+		}
+
+		if err := ctl.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// startEventStream sends the headers of a Server-Sent Events stream;
+// the stream outlives the server write timeout.
+func startEventStream(wri http.ResponseWriter) (*http.ResponseController, error) {
+	ctl := http.NewResponseController(wri)
+	// not all the writers support deadlines (i.e. tests)
+	_ = ctl.SetWriteDeadline(time.Time{})
+
+	wri.Header().Set("Content-Type", "text/event-stream")
+	wri.Header().Set("Cache-Control", "no-cache")
+	wri.Header().Set("Connection", "keep-alive")
+	wri.Header().Set("X-Accel-Buffering", "no")
+	wri.WriteHeader(http.StatusOK)
+
+	return ctl, ctl.Flush()
+}
+
+// watchOptions returns the selection of the watched objects.
+func watchOptions(req *http.Request) (opts dynamic.Options, err error) {
+	opts.GVR, err = util.ParseGVR(req)
+	if err != nil {
+		return
+	}
+
+	query := req.URL.Query()
+	opts.Namespace = query.Get("namespace")
+	opts.LabelSelector = query.Get("labelSelector")
+	if name := query.Get("name"); len(name) > 0 {
+		opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	}
+
+	opts.ResourceVersion = query.Get("resourceVersion")
+	if id := req.Header.Get("Last-Event-ID"); len(id) > 0 {
+		opts.ResourceVersion = id
+	}
+
+	return
+}
+
+// writeEvent writes the watch event as a Server-Sent Event: the event
+// id is the object resource version; bookmarks only update the id.
+// It reports whether the stream is done (i.e. on errors).
+func writeEvent(wri http.ResponseWriter, ev watch.Event) (bool, error) {
+	if ev.Type == watch.Error {
+		status := apierrors.FromObject(ev.Object)
+		dat, err := json.Marshal(watchFailure(status))
+		if err != nil {
+			return true, err
+		}
+		_, err = fmt.Fprintf(wri, "event: %s\ndata: %s\n\n", ev.Type, dat)
+		return true, err
+	}
+
+	obj, err := meta.Accessor(ev.Object)
+	if err != nil {
+		return false, err
+	}
+
+	if ev.Type == watch.Bookmark {
+		_, err = fmt.Fprintf(wri, "id: %s\n\n", obj.GetResourceVersion())
+		return false, err
+	}
+
+	dat, err := json.Marshal(ev.Object)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = fmt.Fprintf(wri, "id: %s\nevent: %s\ndata: %s\n\n", obj.GetResourceVersion(), ev.Type, dat)
+	return false, err
+}
+
+// watchFailure converts the watch error into a failure status.
+func watchFailure(err error) *response.Status {
+	code := http.StatusInternalServerError
+	if el, ok := err.(apierrors.APIStatus); ok && el.Status().Code > 0 {
+		code = int(el.Status().Code)
+	} else if meta.IsNoMatchError(err) {
+		code = http.StatusNotFound
+	}
+
+	status := response.New(code, err)
+	status.Status = response.StatusFailure
+	return status
+}
@@ -7,7 +7,6 @@ import (
 
 	xcontext "github.com/krateoplatformops/plumbing/context"
 	"github.com/krateoplatformops/plumbing/http/response"
-	"github.com/krateoplatformops/plumbing/kubeconfig"
 	templatesv1 "github.com/krateoplatformops/snowplow/apis/templates/v1"
 	"github.com/krateoplatformops/snowplow/internal/dynamic"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -43,21 +42,21 @@ func Get(ctx context.Context, ref templatesv1.ObjectReference) (res Result) {
 		return
 	}
 
-	rc, err := kubeconfig.NewClientConfig(ctx, ep)
+	rc, err := dynamic.ClientConfig(ctx, ep)
 	if err != nil {
 		log.Error("unable to create kubernetes client config", slog.Any("err", err))
 		res.Err = response.New(http.StatusInternalServerError, err)
 		return
 	}
 
-	cli, err := dynamic.NewClient(rc)
+	cli, err := dynamic.ClientFor(ctx, rc)
 	if err != nil {
 		log.Error("unable to create kubernetes dynamic client", slog.Any("err", err))
 		res.Err = response.New(http.StatusInternalServerError, err)
 		return
 	}
 
-	uns, err := cli.Get(context.Background(), ref.Name, dynamic.Options{
+	uns, err := cli.Get(ctx, ref.Name, dynamic.Options{
 		Namespace: ref.Namespace,
 		GVR:       res.GVR,
 	})
@@ -5,7 +5,7 @@ import (
 	"log/slog"
 
 	xcontext "github.com/krateoplatformops/plumbing/context"
-	"github.com/krateoplatformops/plumbing/kubeconfig"
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
 	authv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -27,7 +27,7 @@ func UserCan(ctx context.Context, opts UserCanOptions) (ok bool) {
 		return false
 	}
 
-	rc, err := kubeconfig.NewClientConfig(ctx, ep)
+	rc, err := dynamic.ClientConfig(ctx, ep)
 	if err != nil {
 		log.Error("unable to create user client config", slog.Any("err", err))
 		return false
@@ -23,13 +23,14 @@ func Get(ctx context.Context, opts GetOptions) (map[string]any, error) {
 		}
 
 		var err error
-		opts.RC, err = rest.InClusterConfig()
+		opts.RC, err = dynamic.InClusterConfig(ctx)
 		if err != nil {
 			return map[string]any{}, err
 		}
 	}
 
-	cli, err := dynamic.NewClient(opts.RC)
+	// the discovery cache is shared through the context cache (if any)
+	cli, err := dynamic.ClientFor(ctx, opts.RC)
 	if err != nil {
 		return map[string]any{}, err
 	}
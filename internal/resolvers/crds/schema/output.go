@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Violation is a value not matching the declared schema.
+type Violation struct {
+	// Field is the path of the value (e.g. status.items[0].name).
+	Field string `json:"field"`
+	// Type is the kind of violation (e.g. Invalid value, Required value).
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ViolationsError is returned when a document does not match the schema.
+type ViolationsError struct {
+	Violations []Violation
+}
+
+func (e *ViolationsError) Error() string {
+	all := make([]string, 0, len(e.Violations))
+	for _, el := range e.Violations {
+		all = append(all, fmt.Sprintf("%s: %s: %s", el.Field, el.Type, el.Detail))
+	}
+	return "output schema violations: " + strings.Join(all, "; ")
+}
+
+// CompileSchema builds the validation of the JSON encoded OpenAPI v3 schema;
+// as for widgetData, objects do not allow undeclared properties unless
+// x-kubernetes-preserve-unknown-fields is set.
+func CompileSchema(raw []byte) (validation.SchemaValidator, error) {
+	var data map[string]any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+
+	crv, err := buildValidationFromSchemaData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	validator, _, err := validation.NewSchemaValidator(crv.OpenAPIV3Schema)
+	return validator, err
+}
+
+// ValidateOutput validates the JSON encoded document against the JSON
+// encoded OpenAPI v3 schema; the violations are returned as *ViolationsError
+// with the fields relative to root.
+func ValidateOutput(schema, doc []byte, root string) error {
+	validator, err := CompileSchema(schema)
+	if err != nil {
+		return fmt.Errorf("invalid output schema: %w", err)
+	}
+
+	var obj any
+	if err := json.Unmarshal(doc, &obj); err != nil {
+		return fmt.Errorf("invalid document: %w", err)
+	}
+
+	errs := validation.ValidateCustomResource(field.NewPath(root), obj, validator)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	res := &ViolationsError{Violations: make([]Violation, 0, len(errs))}
+	for _, el := range errs {
+		res.Violations = append(res.Violations, Violation{
+			Field:  el.Field,
+			Type:   el.Type.String(),
+			Detail: el.Detail,
+		})
+	}
+	return res
+}
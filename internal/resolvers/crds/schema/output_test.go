@@ -0,0 +1,62 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOutput(t *testing.T) {
+	schema := []byte(`{
+		"type": "object",
+		"required": ["items"],
+		"properties": {
+			"items": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"required": ["name"],
+					"properties": {
+						"name": {"type": "string"},
+						"age": {"type": "integer"}
+					}
+				}
+			}
+		}
+	}`)
+
+	t.Run("valid document", func(t *testing.T) {
+		err := ValidateOutput(schema, []byte(`{"items": [{"name": "John", "age": 30}]}`), "status")
+		assert.NoError(t, err)
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		err := ValidateOutput(schema, []byte(`{"items": [{"age": "thirty", "extra": true}]}`), "status")
+
+		var verr *ViolationsError
+		if !errors.As(err, &verr) {
+			t.Fatalf("expected violations, got: %v", err)
+		}
+
+		fields := map[string]string{}
+		for _, el := range verr.Violations {
+			fields[el.Field] = el.Type
+		}
+		assert.Equal(t, "Required value", fields["status.items[0].name"])
+		assert.Equal(t, "Invalid value", fields["status.items[0].age"])
+		// undeclared properties are not allowed
+		assert.Equal(t, "Invalid value", fields["status.items[0]"])
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		err := ValidateOutput(schema, []byte(`["John"]`), "status")
+		assert.True(t, errors.As(err, new(*ViolationsError)))
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		err := ValidateOutput([]byte(`{"properties": "name"}`), []byte(`{}`), "status")
+		assert.Error(t, err)
+		assert.False(t, errors.As(err, new(*ViolationsError)))
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/krateoplatformops/plumbing/env"
 	"github.com/krateoplatformops/snowplow/internal/dynamic"
 	"github.com/krateoplatformops/snowplow/internal/resolvers/crds"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -18,8 +19,17 @@ const (
 )
 
 func ValidateObjectStatus(ctx context.Context, rc *rest.Config, obj map[string]any) error {
+	if rc == nil && !env.TestMode() {
+		// shared with the CRD lookup below through the context cache (if any)
+		var err error
+		rc, err = dynamic.InClusterConfig(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	gv := dynamic.GroupVersion(obj)
-	gvr, err := dynamic.ResourceFor(rc, gv.WithKind(dynamic.GetKind(obj)))
+	gvr, err := dynamic.ResourceForContext(ctx, rc, gv.WithKind(dynamic.GetKind(obj)))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,93 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// fakeDiscoveryServer serves the discovery of the widgets group and the
+// widget CRD, counting the discovery requests.
+func fakeDiscoveryServer(t *testing.T, discoveries *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	crd := `{"apiVersion":"apiextensions.k8s.io/v1","kind":"CustomResourceDefinition",
+		"metadata":{"name":"tables.widgets.templates.krateo.io"},
+		"spec":{"versions":[{"name":"v1beta1","schema":{"openAPIV3Schema":{"type":"object","properties":{
+			"spec":{"type":"object","properties":{"widgetData":{"type":"object","properties":{"title":{"type":"string"}}}}}}}}}]}}`
+
+	mux := http.NewServeMux()
+	discovery := func(pattern, body string) {
+		mux.HandleFunc("GET "+pattern, func(wri http.ResponseWriter, _ *http.Request) {
+			discoveries.Add(1)
+			wri.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(wri, body)
+		})
+	}
+	discovery("/api", `{"kind":"APIVersions","versions":["v1"]}`)
+	discovery("/api/v1", `{"kind":"APIResourceList","groupVersion":"v1","resources":[]}`)
+	discovery("/apis", `{"kind":"APIGroupList","apiVersion":"v1","groups":[
+		{"name":"widgets.templates.krateo.io","versions":[{"groupVersion":"widgets.templates.krateo.io/v1beta1","version":"v1beta1"}],
+		 "preferredVersion":{"groupVersion":"widgets.templates.krateo.io/v1beta1","version":"v1beta1"}},
+		{"name":"apiextensions.k8s.io","versions":[{"groupVersion":"apiextensions.k8s.io/v1","version":"v1"}],
+		 "preferredVersion":{"groupVersion":"apiextensions.k8s.io/v1","version":"v1"}}]}`)
+	discovery("/apis/widgets.templates.krateo.io/v1beta1", `{"kind":"APIResourceList","groupVersion":"widgets.templates.krateo.io/v1beta1","resources":[
+		{"name":"tables","singularName":"table","namespaced":true,"kind":"Table","verbs":["get","list"]}]}`)
+	discovery("/apis/apiextensions.k8s.io/v1", `{"kind":"APIResourceList","groupVersion":"apiextensions.k8s.io/v1","resources":[
+		{"name":"customresourcedefinitions","singularName":"customresourcedefinition","namespaced":false,"kind":"CustomResourceDefinition","verbs":["get","list"]}]}`)
+
+	mux.HandleFunc("GET /apis/apiextensions.k8s.io/v1/customresourcedefinitions/tables.widgets.templates.krateo.io", func(wri http.ResponseWriter, _ *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(wri, crd)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestValidateObjectStatusDiscovery(t *testing.T) {
+	var discoveries atomic.Int32
+	srv := fakeDiscoveryServer(t, &discoveries)
+	defer srv.Close()
+
+	rc := &rest.Config{Host: srv.URL}
+
+	validate := func(ctx context.Context, count int) int32 {
+		discoveries.Store(0)
+		for i := range count {
+			obj := map[string]any{
+				"apiVersion": "widgets.templates.krateo.io/v1beta1",
+				"kind":       "Table",
+				"metadata":   map[string]any{"name": fmt.Sprintf("table-%d", i), "namespace": "demo"},
+				"status": map[string]any{
+					"widgetData": map[string]any{"title": strings.Repeat("x", i)},
+				},
+			}
+			if err := ValidateObjectStatus(ctx, rc, obj); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		return discoveries.Load()
+	}
+
+	single := validate(dynamic.WithCache(context.Background(), dynamic.NewCache()), 1)
+	if single == 0 {
+		t.Fatal("expected the discovery to be performed")
+	}
+
+	// the batch shares the discovery through the context cache
+	if got := validate(dynamic.WithCache(context.Background(), dynamic.NewCache()), 3); got != single {
+		t.Errorf("expected %d discovery requests for the batch, got %d", single, got)
+	}
+
+	// without a cache every validation discovers again
+	if got := validate(context.Background(), 3); got < 3*single {
+		t.Errorf("expected at least %d discovery requests without cache, got %d", 3*single, got)
+	}
+}
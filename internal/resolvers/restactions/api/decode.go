@@ -0,0 +1,181 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var acceptHeaders = map[templates.ResponseFormat]string{
+	templates.ResponseFormatJSON: "Accept: application/json",
+	templates.ResponseFormatYAML: "Accept: application/yaml",
+	templates.ResponseFormatXML:  "Accept: application/xml",
+	templates.ResponseFormatCSV:  "Accept: text/csv",
+	templates.ResponseFormatText: "Accept: text/plain",
+}
+
+// responseFormat returns the explicit format, if any, otherwise
+// the format inferred from the response content type.
+func responseFormat(explicit *templates.ResponseFormat, contentType string) templates.ResponseFormat {
+	if explicit != nil && len(*explicit) > 0 {
+		return *explicit
+	}
+
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	switch {
+	case strings.Contains(mt, "json"):
+		return templates.ResponseFormatJSON
+	case strings.Contains(mt, "yaml"):
+		return templates.ResponseFormatYAML
+	case strings.Contains(mt, "xml"):
+		return templates.ResponseFormatXML
+	case strings.Contains(mt, "csv"):
+		return templates.ResponseFormatCSV
+	case strings.HasPrefix(mt, "text/"):
+		return templates.ResponseFormatText
+	default:
+		return templates.ResponseFormatJSON
+	}
+}
+
+// decodeBody converts the response body into a JSON compatible value.
+func decodeBody(format templates.ResponseFormat, dat []byte) (any, error) {
+	var res any
+
+	switch format {
+	case templates.ResponseFormatJSON, "":
+		if err := json.Unmarshal(dat, &res); err != nil {
+			return nil, err
+		}
+	case templates.ResponseFormatYAML:
+		if err := yaml.Unmarshal(dat, &res); err != nil {
+			return nil, err
+		}
+	case templates.ResponseFormatXML:
+		return decodeXML(dat)
+	case templates.ResponseFormatCSV:
+		return decodeCSV(dat)
+	case templates.ResponseFormatText:
+		return string(dat), nil
+	default:
+		return nil, fmt.Errorf("unsupported response format %q", format)
+	}
+
+	return res, nil
+}
+
+// decodeCSV returns an array of objects, one for each record,
+// keyed by the column names in the header record.
+func decodeCSV(dat []byte) (any, error) {
+	rd := csv.NewReader(bytes.NewReader(dat))
+	rd.FieldsPerRecord = -1
+	rd.TrimLeadingSpace = true
+
+	records, err := rd.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	all := []any{}
+	if len(records) == 0 {
+		return all, nil
+	}
+
+	header := records[0]
+	for _, rec := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, col := range header {
+			if i < len(rec) {
+				row[col] = rec[i]
+			} else {
+				row[col] = nil
+			}
+		}
+		all = append(all, row)
+	}
+
+	return all, nil
+}
+
+// decodeXML converts an XML document into a map keyed by the root element name.
+// Attributes become `@name` keys, repeated elements become arrays and
+// the text of elements having attributes or children is stored as `#text`.
+func decodeXML(dat []byte) (any, error) {
+	dec := xml.NewDecoder(bytes.NewReader(dat))
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("empty XML document")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			val, err := decodeXMLElement(dec, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{start.Name.Local: val}, nil
+		}
+	}
+}
+
+func decodeXMLElement(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	node := map[string]any{}
+	for _, attr := range start.Attr {
+		node["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(dec, el)
+			if err != nil {
+				return nil, err
+			}
+
+			key := el.Name.Local
+			switch got := node[key].(type) {
+			case nil:
+				node[key] = child
+			case []any:
+				node[key] = append(got, child)
+			default:
+				node[key] = []any{got, child}
+			}
+
+		case xml.CharData:
+			text.Write(el)
+
+		case xml.EndElement:
+			str := strings.TrimSpace(text.String())
+			if len(node) == 0 {
+				return str, nil
+			}
+			if len(str) > 0 {
+				node["#text"] = str
+			}
+			return node, nil
+		}
+	}
+}
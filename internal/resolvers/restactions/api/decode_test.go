@@ -0,0 +1,178 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/endpoints"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+)
+
+func TestResponseFormat(t *testing.T) {
+	tests := []struct {
+		explicit    *templates.ResponseFormat
+		contentType string
+		want        templates.ResponseFormat
+	}{
+		{contentType: "application/json; charset=utf-8", want: templates.ResponseFormatJSON},
+		{contentType: "application/problem+json", want: templates.ResponseFormatJSON},
+		{contentType: "application/yaml", want: templates.ResponseFormatYAML},
+		{contentType: "text/x-yaml", want: templates.ResponseFormatYAML},
+		{contentType: "application/xml", want: templates.ResponseFormatXML},
+		{contentType: "text/csv", want: templates.ResponseFormatCSV},
+		{contentType: "text/plain; version=0.0.4", want: templates.ResponseFormatText},
+		{contentType: "", want: templates.ResponseFormatJSON},
+		{
+			explicit:    ptr.To(templates.ResponseFormatCSV),
+			contentType: "text/plain", want: templates.ResponseFormatCSV,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.contentType, func(t *testing.T) {
+			if got := responseFormat(tc.explicit, tc.contentType); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name   string
+		format templates.ResponseFormat
+		input  string
+		expect any
+		err    bool
+	}{
+		{
+			name:   "json",
+			format: templates.ResponseFormatJSON,
+			input:  `{"foo": ["bar"]}`,
+			expect: map[string]any{"foo": []any{"bar"}},
+		},
+		{
+			name:   "yaml",
+			format: templates.ResponseFormatYAML,
+			input:  "foo:\n  - bar\nnum: 42\n",
+			expect: map[string]any{"foo": []any{"bar"}, "num": 42},
+		},
+		{
+			name:   "xml",
+			format: templates.ResponseFormatXML,
+			input: `<?xml version="1.0"?>
+<users count="2">
+  <user id="1">alice</user>
+  <user id="2">bob</user>
+  <owner>carol</owner>
+</users>`,
+			expect: map[string]any{
+				"users": map[string]any{
+					"@count": "2",
+					"owner":  "carol",
+					"user": []any{
+						map[string]any{"@id": "1", "#text": "alice"},
+						map[string]any{"@id": "2", "#text": "bob"},
+					},
+				},
+			},
+		},
+		{
+			name:   "csv",
+			format: templates.ResponseFormatCSV,
+			input:  "name,age\nalice,30\nbob,25\n",
+			expect: []any{
+				map[string]any{"name": "alice", "age": "30"},
+				map[string]any{"name": "bob", "age": "25"},
+			},
+		},
+		{
+			name:   "text",
+			format: templates.ResponseFormatText,
+			input:  "up 1\n",
+			expect: "up 1\n",
+		},
+		{
+			name:   "invalid xml",
+			format: templates.ResponseFormatXML,
+			input:  "<users><user>",
+			err:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decodeBody(tc.format, []byte(tc.input))
+			if (err != nil) != tc.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !tc.err && !deepEqual(got, tc.expect) {
+				t.Errorf("expected %v, got %v", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestDoCallWithNonJSONResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metrics":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("requests_total 7"))
+		case "/export":
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write([]byte("id,name\n1,alice\n"))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("<h1>not found</h1>"))
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		filter *string
+		expect any
+		code   int
+	}{
+		{path: "/metrics", expect: "requests_total 7"},
+		{path: "/export", filter: ptr.To(".test | map(.name)"), expect: []any{"alice"}},
+		{path: "/missing", code: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			res := doCall(context.Background(), callOptions{
+				id: "test",
+				call: httpcall.RequestOptions{
+					RequestInfo: httpcall.RequestInfo{Path: tc.path},
+					Endpoint:    &endpoints.Endpoint{ServerURL: srv.URL},
+				},
+				filter: tc.filter,
+			})
+
+			if tc.code != 0 {
+				if !res.failed() || res.status.Code != tc.code {
+					t.Fatalf("expected failure with code %d, got: %v", tc.code, res.status)
+				}
+				return
+			}
+
+			if res.failed() {
+				t.Fatalf("unexpected failure: %v", res.status)
+			}
+			if !deepEqual(res.out["test"], tc.expect) {
+				t.Errorf("expected %v, got %v", tc.expect, res.out["test"])
+			}
+		})
+	}
+}
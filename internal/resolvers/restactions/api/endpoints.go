@@ -3,11 +3,18 @@ package api
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"sync"
 
 	"github.com/krateoplatformops/plumbing/endpoints"
 	"github.com/krateoplatformops/plumbing/env"
 	"github.com/krateoplatformops/plumbing/kubeutil"
 	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/fixtures"
+	"github.com/krateoplatformops/snowplow/internal/signing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
@@ -15,6 +22,13 @@ type endpointReferenceMapper struct {
 	authnNS  string
 	username string
 	rc       *rest.Config
+	// cli, if set, is used in place of a client created from rc.
+	cli kubernetes.Interface
+
+	mu sync.Mutex
+	// secrets are the endpoint secrets already read (by namespace/name):
+	// the mapper lives for a single Resolve.
+	secrets map[string]*corev1.Secret
 }
 
 func (m *endpointReferenceMapper) resolveOne(ctx context.Context, ref *templates.Reference) (endpoints.Endpoint, error) {
@@ -27,13 +41,133 @@ func (m *endpointReferenceMapper) resolveOne(ctx context.Context, ref *templates
 		isInternal = true
 	}
 
-	ep, err := endpoints.FromSecret(ctx, m.rc, ref.Name, ref.Namespace)
+	key := fixtureEndpointKey(ref, isInternal)
+	sess := fixtures.FromContext(ctx)
+	if sess.Replaying() {
+		// credentials are never recorded: only the server URL is replayed
+		url, err := sess.Endpoint(key)
+		return endpoints.Endpoint{ServerURL: url}, err
+	}
+
+	sec, err := m.secret(ctx, ref)
+	if err != nil {
+		return endpoints.Endpoint{}, err
+	}
+
+	ep, err := endpointFromSecret(sec)
 	if err != nil {
 		return ep, err
 	}
 	if isInternal && !env.TestMode() {
 		ep.ServerURL = "https://kubernetes.default.svc"
 	}
+	sess.SetEndpoint(key, ep.ServerURL)
 
 	return ep, nil
 }
+
+// secret returns the referenced endpoint secret, read once for all the calls.
+func (m *endpointReferenceMapper) secret(ctx context.Context, ref *templates.Reference) (*corev1.Secret, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := ref.Namespace + "/" + ref.Name
+	if sec, ok := m.secrets[key]; ok {
+		return sec, nil
+	}
+
+	if m.cli == nil {
+		cli, err := kubernetes.NewForConfig(m.rc)
+		if err != nil {
+			return nil, err
+		}
+		m.cli = cli
+	}
+
+	sec, err := m.cli.CoreV1().Secrets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if m.secrets == nil {
+		m.secrets = make(map[string]*corev1.Secret)
+	}
+	m.secrets[key] = sec
+
+	return sec, nil
+}
+
+// endpointFromSecret returns the endpoint defined by the secret,
+// as endpoints.FromSecret does.
+func endpointFromSecret(sec *corev1.Secret) (endpoints.Endpoint, error) {
+	res := endpoints.Endpoint{}
+
+	v, ok := sec.Data["server-url"]
+	if !ok {
+		return res, fmt.Errorf("missed required attribute for endpoint: server-url")
+	}
+	res.ServerURL = string(v)
+
+	res.ProxyURL = string(sec.Data["proxy-url"])
+	res.Token = string(sec.Data["token"])
+	res.Username = string(sec.Data["username"])
+	res.Password = string(sec.Data["password"])
+	res.CertificateAuthorityData = string(sec.Data["certificate-authority-data"])
+	res.ClientKeyData = string(sec.Data["client-key-data"])
+	res.ClientCertificateData = string(sec.Data["client-certificate-data"])
+	res.Debug, _ = strconv.ParseBool(string(sec.Data["debug"]))
+	res.Insecure, _ = strconv.ParseBool(string(sec.Data["insecure"]))
+	res.AwsAccessKey = string(sec.Data["aws-access-key"])
+	res.AwsSecretKey = string(sec.Data["aws-secret-key"])
+	res.AwsRegion = string(sec.Data["aws-region"])
+	res.AwsService = string(sec.Data["aws-service"])
+
+	return res, nil
+}
+
+func fixtureEndpointKey(ref *templates.Reference, internal bool) string {
+	if internal {
+		return fixtures.InternalEndpoint
+	}
+	return ref.Namespace + "/" + ref.Name
+}
+
+// endpointExtensions are the endpoint secret settings
+// not handled by endpoints.Endpoint.
+type endpointExtensions struct {
+	oauth2 *oauth2Config
+	signer signing.Signer
+}
+
+// extensions returns the OAuth2 configuration and the request signer declared
+// by the endpoint secret (if any), the one already read by resolveOne;
+// the internal endpoints never use them.
+func (m *endpointReferenceMapper) extensions(ctx context.Context, ref *templates.Reference, ep endpoints.Endpoint) (res endpointExtensions, err error) {
+	if ref == nil || fixtures.FromContext(ctx).Replaying() {
+		return res, nil
+	}
+
+	sec, err := m.secret(ctx, ref)
+	if err != nil {
+		return res, err
+	}
+
+	res.oauth2, err = oauth2FromSecret(sec, ep)
+	if err != nil {
+		return res, err
+	}
+
+	cfg := make(signing.Config, len(sec.Data))
+	for k, v := range sec.Data {
+		cfg[k] = string(v)
+	}
+	res.signer, err = signing.New(cfg)
+	if err != nil {
+		return res, err
+	}
+	if res.signer != nil && ep.HasAwsAuth() {
+		return res, fmt.Errorf("only one of %s and AWS must be set", signing.SchemeLabel)
+	}
+
+	return res, nil
+}
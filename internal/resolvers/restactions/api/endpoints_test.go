@@ -0,0 +1,169 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/endpoints"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"github.com/krateoplatformops/snowplow/internal/signing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestEndpointExtensions(t *testing.T) {
+	secret := func(name string, data map[string]string) *corev1.Secret {
+		res := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "demo"},
+			Data:       map[string][]byte{"server-url": []byte("https://example.com")},
+		}
+		for k, v := range data {
+			res.Data[k] = []byte(v)
+		}
+		return res
+	}
+
+	m := &endpointReferenceMapper{cli: fake.NewSimpleClientset(
+		secret("plain", nil),
+		secret("signed", map[string]string{
+			signing.SchemeLabel: signing.SchemeHMAC, signing.HMACSecretLabel: "s3cr3t",
+		}),
+		secret("oauth2", map[string]string{
+			oauth2TokenURLLabel: "https://login.example.com/token", oauth2ClientIDLabel: "app",
+		}),
+		secret("unknown", map[string]string{signing.SchemeLabel: "nope"}),
+	)}
+
+	tests := []struct {
+		name   string
+		ref    *templates.Reference
+		ep     endpoints.Endpoint
+		signer bool
+		oauth2 bool
+		err    bool
+	}{
+		{name: "internal"},
+		{name: "plain", ref: &templates.Reference{Name: "plain", Namespace: "demo"}},
+		{name: "signed", ref: &templates.Reference{Name: "signed", Namespace: "demo"}, signer: true},
+		{name: "oauth2", ref: &templates.Reference{Name: "oauth2", Namespace: "demo"}, oauth2: true},
+		{name: "unknown scheme", ref: &templates.Reference{Name: "unknown", Namespace: "demo"}, err: true},
+		{
+			name: "signed with aws",
+			ref:  &templates.Reference{Name: "signed", Namespace: "demo"},
+			ep: endpoints.Endpoint{
+				AwsAccessKey: "key", AwsSecretKey: "secret", AwsRegion: "eu-west-1", AwsService: "s3",
+			},
+			err: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := m.extensions(context.Background(), tc.ref, tc.ep)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (got.signer != nil) != tc.signer || (got.oauth2 != nil) != tc.oauth2 {
+				t.Errorf("unexpected extensions: %+v", got)
+			}
+		})
+	}
+}
+
+func TestEndpointSecretReadOnce(t *testing.T) {
+	cli := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "signed", Namespace: "demo"},
+		Data: map[string][]byte{
+			"server-url":            []byte("https://example.com"),
+			"token":                 []byte("t0k3n"),
+			signing.SchemeLabel:     []byte(signing.SchemeHMAC),
+			signing.HMACSecretLabel: []byte("s3cr3t"),
+		},
+	})
+
+	gets := 0
+	cli.PrependReactor("get", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		gets++
+		return false, nil, nil
+	})
+
+	m := &endpointReferenceMapper{cli: cli}
+	ref := &templates.Reference{Name: "signed", Namespace: "demo"}
+
+	for range 2 {
+		ep, err := m.resolveOne(context.Background(), ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ep.ServerURL != "https://example.com" || ep.Token != "t0k3n" {
+			t.Errorf("unexpected endpoint: %+v", ep)
+		}
+
+		ext, err := m.extensions(context.Background(), ref, ep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ext.signer == nil {
+			t.Errorf("expected the request signer")
+		}
+	}
+
+	if gets != 1 {
+		t.Errorf("expected the secret to be read once, got %d reads", gets)
+	}
+}
+
+func TestDoCallSigned(t *testing.T) {
+	const payload = `{"event": "deploy"}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := "sha256=" + signing.HMACSignature([]byte("s3cr3t"), body)
+		if r.Header.Get("X-Signature-256") != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	signer, err := signing.New(signing.Config{
+		signing.SchemeLabel: signing.SchemeHMAC, signing.HMACSecretLabel: "s3cr3t",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := doCall(context.Background(), callOptions{
+		id: "test",
+		call: httpcall.RequestOptions{
+			RequestInfo: httpcall.RequestInfo{
+				Path: "/hooks", Verb: ptr.To(http.MethodPost), Payload: ptr.To(payload),
+			},
+			Endpoint: &endpoints.Endpoint{ServerURL: srv.URL},
+		},
+		signer: signer,
+	})
+	if res.failed() {
+		t.Fatalf("unexpected failure: %v", res.status)
+	}
+}
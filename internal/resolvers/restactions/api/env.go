@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/jqutil"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const redactedValue = "<redacted>"
+
+// envSource reads the values referenced by the APIs env from the
+// Secrets and ConfigMaps of the RESTAction namespace, using the
+// service account credentials.
+type envSource struct {
+	rc        *rest.Config
+	namespace string
+	// cli, if set, is used in place of a client created from rc.
+	cli kubernetes.Interface
+}
+
+// resolve returns the value of each variable.
+func (s *envSource) resolve(ctx context.Context, items []templates.EnvVar) (map[string]string, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	if s == nil || len(s.namespace) == 0 {
+		return nil, fmt.Errorf("env values require the RESTAction namespace")
+	}
+
+	cli := s.cli
+	if cli == nil {
+		var err error
+		cli, err = kubernetes.NewForConfig(s.rc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := make(map[string]string, len(items))
+	for _, el := range items {
+		val, err := s.value(ctx, cli, el)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve env %q: %w", el.Name, err)
+		}
+		res[el.Name] = val
+	}
+
+	return res, nil
+}
+
+func (s *envSource) value(ctx context.Context, cli kubernetes.Interface, in templates.EnvVar) (string, error) {
+	switch src := in.ValueFrom; {
+	case src.SecretKeyRef != nil:
+		ref := src.SecretKeyRef
+		obj, err := cli.CoreV1().Secrets(s.namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		val, ok := obj.Data[ref.Key]
+		if !ok {
+			return "", fmt.Errorf("key %q not found in secret %s/%s", ref.Key, s.namespace, ref.Name)
+		}
+		return string(val), nil
+
+	case src.ConfigMapKeyRef != nil:
+		ref := src.ConfigMapKeyRef
+		obj, err := cli.CoreV1().ConfigMaps(s.namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		val, ok := obj.Data[ref.Key]
+		if !ok {
+			return "", fmt.Errorf("key %q not found in configmap %s/%s", ref.Key, s.namespace, ref.Name)
+		}
+		return val, nil
+
+	default:
+		return "", fmt.Errorf("one of secretKeyRef or configMapKeyRef is required")
+	}
+}
+
+// expandEnv replaces each $(NAME) occurrence with the variable value;
+// references to unknown variables are left unchanged.
+func expandEnv(s string, env map[string]string) string {
+	if len(env) == 0 || !strings.Contains(s, "$(") {
+		return s
+	}
+
+	pairs := make([]string, 0, len(env)*2)
+	for k, v := range env {
+		pairs = append(pairs, "$("+k+")", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// expandTemplate replaces the $(NAME) references of the author written
+// template before its evaluation, so that the values coming from the
+// evaluated data (i.e. extras, API responses) are never expanded.
+// Inside JQ expressions the values are escaped as string literal content.
+func expandTemplate(s string, env map[string]string) string {
+	if _, ok := jqutil.MaybeQuery(s); !ok || len(env) == 0 {
+		return expandEnv(s, env)
+	}
+
+	escaped := make(map[string]string, len(env))
+	for k, v := range env {
+		dat, _ := json.Marshal(v)
+		escaped[k] = string(dat[1 : len(dat)-1])
+	}
+	return expandEnv(s, escaped)
+}
+
+// secretValue is an env value that must never be logged or traced.
+type secretValue struct {
+	name  string
+	value string
+}
+
+type secretValues []secretValue
+
+func envSecrets(env map[string]string) secretValues {
+	all := make(secretValues, 0, len(env))
+	for k, v := range env {
+		if len(v) > 0 {
+			all = append(all, secretValue{name: k, value: v})
+		}
+	}
+	// longest first, so that a value contained in another is not partially redacted
+	sort.Slice(all, func(i, j int) bool { return len(all[i].value) > len(all[j].value) })
+	return all
+}
+
+// redact replaces the secret values contained in s.
+func (sv secretValues) redact(s string) string {
+	for _, el := range sv {
+		s = strings.ReplaceAll(s, el.value, redactedValue)
+	}
+	return s
+}
+
+// restore replaces the secret values contained in s with their $(NAME) reference.
+func (sv secretValues) restore(s string) string {
+	for _, el := range sv {
+		s = strings.ReplaceAll(s, el.value, "$("+el.name+")")
+	}
+	return s
+}
+
+// redactCall returns a copy of the call, safe to be logged or traced.
+func (sv secretValues) redactCall(call httpcall.RequestOptions) httpcall.RequestOptions {
+	if len(sv) == 0 {
+		return call
+	}
+
+	call.Path = sv.redact(call.Path)
+	headers := make([]string, 0, len(call.Headers))
+	for _, el := range call.Headers {
+		headers = append(headers, sv.redact(el))
+	}
+	call.Headers = headers
+	call.Payload = nil
+	return call
+}
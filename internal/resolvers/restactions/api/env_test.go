@@ -0,0 +1,125 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnvSourceResolve(t *testing.T) {
+	cli := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "demo"},
+			Data:       map[string][]byte{"token": []byte("s3cr3t")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "demo"},
+			Data:       map[string]string{"region": "eu-west-1"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "other"},
+			Data:       map[string][]byte{"token": []byte("other")},
+		},
+	)
+
+	src := &envSource{namespace: "demo", cli: cli}
+
+	got, err := src.resolve(context.Background(), []templates.EnvVar{
+		{
+			Name: "TOKEN",
+			ValueFrom: templates.EnvVarSource{
+				SecretKeyRef: &templates.KeySelector{Name: "tenant", Key: "token"},
+			},
+		},
+		{
+			Name: "REGION",
+			ValueFrom: templates.EnvVarSource{
+				ConfigMapKeyRef: &templates.KeySelector{Name: "tenant", Key: "region"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got["TOKEN"] != "s3cr3t" || got["REGION"] != "eu-west-1" {
+		t.Errorf("unexpected values: %v", got)
+	}
+
+	_, err = src.resolve(context.Background(), []templates.EnvVar{
+		{
+			Name: "MISSING",
+			ValueFrom: templates.EnvVarSource{
+				SecretKeyRef: &templates.KeySelector{Name: "tenant", Key: "missing"},
+			},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), `key "missing" not found`) {
+		t.Errorf("expected missing key error, got %v", err)
+	}
+
+	_, err = src.resolve(context.Background(), []templates.EnvVar{
+		{
+			Name: "NOPE",
+			ValueFrom: templates.EnvVarSource{
+				SecretKeyRef: &templates.KeySelector{Name: "nope", Key: "token"},
+			},
+		},
+	})
+	if got := statusFromError(err); got.Code != 404 {
+		t.Errorf("expected 404, got %d", got.Code)
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	env := map[string]string{"TOKEN": "s3cr3t", "REGION": "eu", "QUOTED": `a"b`}
+
+	dict := map[string]any{
+		"extras": map[string]any{"q": "$(TOKEN)"},
+		"api":    map[string]any{"next": "/next?key=$(TOKEN)"},
+	}
+
+	all := createRequestOptions(slog.New(slog.DiscardHandler), &templates.API{
+		Name:    "tickets",
+		Path:    `${ "/items?region=$(REGION)&key=$(TOKEN)&q=" + .extras.q + "&other=$(OTHER)" }`,
+		Headers: []string{"X-Api-Key: $(TOKEN)", `${ "X-Quoted: $(QUOTED)" }`, `${ "X-Next: " + .api.next }`},
+		Payload: ptr.To(`{"token": "$(TOKEN)", "q": "$(OTHER)"}`),
+	}, dict, env)
+	if len(all) != 1 {
+		t.Fatalf("expected 1 call, got %d", len(all))
+	}
+	call := all[0]
+
+	// the values of the evaluated data are never expanded
+	if call.Path != "/items?region=eu&key=s3cr3t&q=$(TOKEN)&other=$(OTHER)" {
+		t.Errorf("unexpected path: %s", call.Path)
+	}
+	want := []string{"X-Api-Key: s3cr3t", `X-Quoted: a"b`, "X-Next: /next?key=$(TOKEN)"}
+	if strings.Join(call.Headers, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected headers: %v", call.Headers)
+	}
+	if ptr.Deref(call.Payload, "") != `{"token": "s3cr3t", "q": "$(OTHER)"}` {
+		t.Errorf("unexpected payload: %s", ptr.Deref(call.Payload, ""))
+	}
+
+	shown := envSecrets(env).redactCall(call)
+	if strings.Contains(shown.Path+strings.Join(shown.Headers, ""), "s3cr3t") {
+		t.Errorf("secret not redacted: %s %v", shown.Path, shown.Headers)
+	}
+	if shown.Payload != nil {
+		t.Errorf("expected no payload in redacted call")
+	}
+	if call.Headers[0] != "X-Api-Key: s3cr3t" {
+		t.Errorf("redaction must not change the original call")
+	}
+}
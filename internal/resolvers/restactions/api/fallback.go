@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/jqutil"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	jqsupport "github.com/krateoplatformops/snowplow/internal/support/jq"
+)
+
+// recoverStep reshapes the error stored under the error key with the
+// API errorFilter and stores the API fallback under the API name,
+// when the step has failed; the step still reports the failure.
+func recoverStep(ctx context.Context, in *templates.API, dict map[string]any, res *stepResult) {
+	if res.failure == nil || res.skipped {
+		return
+	}
+
+	log := xcontext.Logger(ctx)
+
+	if in.ErrorFilter != nil {
+		key := ptr.Deref(in.ErrorKey, "error")
+		if val, ok := res.out[key]; ok {
+			got, err := evalValue(ptr.Deref(in.ErrorFilter, ""), val)
+			if err != nil {
+				log.Warn("unable to apply error filter", slog.String("name", in.Name), slog.Any("err", err))
+			} else {
+				res.out[key] = got
+			}
+		}
+	}
+
+	if in.Fallback == nil || len(in.Fallback.Raw) == 0 {
+		return
+	}
+
+	val, err := fallbackValue(in.Fallback.Raw, dict)
+	if err != nil {
+		log.Error("unable to evaluate api fallback", slog.String("name", in.Name), slog.Any("err", err))
+		return
+	}
+
+	log.Info("api fallback stored", slog.String("name", in.Name),
+		slog.Int("code", res.failure.Code), slog.String("error", res.failure.Message))
+	res.out[in.Name] = val
+}
+
+// fallbackValue decodes the JSON encoded fallback; a string wrapped
+// in `${ }` is evaluated as JQ expression against the data source.
+func fallbackValue(raw []byte, ds any) (any, error) {
+	var val any
+	if err := json.Unmarshal(raw, &val); err != nil {
+		return nil, fmt.Errorf("invalid fallback: %w", err)
+	}
+
+	s, ok := val.(string)
+	if !ok {
+		return val, nil
+	}
+
+	q, ok := jqutil.MaybeQuery(s)
+	if !ok {
+		return s, nil
+	}
+
+	return evalValue(q, ds)
+}
+
+// evalValue evaluates the JQ expression and decodes the result as JSON value.
+func evalValue(q string, ds any) (any, error) {
+	s, err := jqutil.Eval(context.TODO(), jqutil.EvalOptions{
+		Query: q, Data: ds,
+		ModuleLoader: jqsupport.ModuleLoader(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to evaluate %q: %w", q, err)
+	}
+
+	var val any
+	if len(strings.TrimSpace(s)) > 0 {
+		if err := json.Unmarshal([]byte(s), &val); err != nil {
+			return nil, fmt.Errorf("unable to decode %q result: %w", q, err)
+		}
+	}
+	return val, nil
+}
@@ -0,0 +1,95 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"testing"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRecoverStep(t *testing.T) {
+	ctx := xcontext.BuildContext(context.Background(),
+		xcontext.WithLogger(slog.New(slog.DiscardHandler)))
+
+	dict := map[string]any{"extras": map[string]any{"label": "no data"}}
+
+	tests := []struct {
+		name   string
+		api    templates.API
+		failed bool
+		expect map[string]any
+	}{
+		{
+			name: "success",
+			api: templates.API{
+				Name: "users", Fallback: &runtime.RawExtension{Raw: []byte(`[]`)},
+			},
+			expect: map[string]any{"users": []any{"alice"}},
+		},
+		{
+			name:   "no fallback",
+			api:    templates.API{Name: "users"},
+			failed: true,
+			expect: map[string]any{
+				"users": []any{"alice"},
+				"error": map[string]any{"code": 404.0, "message": `{"detail":"not here"}`},
+			},
+		},
+		{
+			name: "literal",
+			api: templates.API{
+				Name: "users", Fallback: &runtime.RawExtension{Raw: []byte(`[]`)},
+			},
+			failed: true,
+			expect: map[string]any{
+				"users": []any{},
+				"error": map[string]any{"code": 404.0, "message": `{"detail":"not here"}`},
+			},
+		},
+		{
+			name: "expression and error filter",
+			api: templates.API{
+				Name:        "users",
+				ErrorKey:    ptr.To("usersError"),
+				Fallback:    &runtime.RawExtension{Raw: []byte(`"${ {items: [], message: .extras.label} }"`)},
+				ErrorFilter: ptr.To(`{code, reason: (.message | fromjson | .detail)}`),
+			},
+			failed: true,
+			expect: map[string]any{
+				"users":      map[string]any{"items": []any{}, "message": "no data"},
+				"usersError": map[string]any{"code": 404.0, "reason": "not here"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key := ptr.Deref(tc.api.ErrorKey, "error")
+
+			res := stepResult{out: map[string]any{"users": []any{"alice"}}}
+			if tc.failed {
+				res.out[key] = map[string]any{"code": 404.0, "message": `{"detail":"not here"}`}
+				res.fail(response.New(http.StatusNotFound, fmt.Errorf("not here")), true)
+			}
+
+			recoverStep(ctx, &tc.api, dict, &res)
+			if !reflect.DeepEqual(res.out, tc.expect) {
+				t.Fatalf("expected %v, got %v", tc.expect, res.out)
+			}
+			if tc.failed && res.failure == nil {
+				t.Fatalf("expected the failure to be kept")
+			}
+		})
+	}
+}
@@ -9,78 +9,106 @@ import (
 	xcontext "github.com/krateoplatformops/plumbing/context"
 	"github.com/krateoplatformops/plumbing/jqutil"
 	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
 	jqsupport "github.com/krateoplatformops/snowplow/internal/support/jq"
 )
 
 type jsonHandlerOptions struct {
 	key    string
 	out    map[string]any
+	slice  any
 	filter *string
+	format templates.ResponseFormat
+	// decoded, if set, receives the response body before the filter.
+	decoded func(any)
 }
 
 func jsonHandler(ctx context.Context, opts jsonHandlerOptions) func(io.ReadCloser) error {
 	return func(in io.ReadCloser) error {
-		log := xcontext.Logger(ctx)
-
 		dat, err := io.ReadAll(in)
 		if err != nil {
 			return err
 		}
 
-		var tmp any
-		if err := json.Unmarshal(dat, &tmp); err != nil {
+		tmp, err := decodeBody(opts.format, dat)
+		if err != nil {
 			return err
 		}
-
-		pig := map[string]any{
-			opts.key: tmp,
-		}
-		if si, ok := opts.out["slice"]; ok {
-			pig["slice"] = si
+		if opts.decoded != nil {
+			opts.decoded(tmp)
 		}
 
-		if opts.filter != nil {
-			q := ptr.Deref(opts.filter, "")
-			log.Debug("found local filter on api result", slog.String("filter", q))
-			s, err := jqutil.Eval(context.TODO(), jqutil.EvalOptions{
-				Query: q, Data: pig,
-				ModuleLoader: jqsupport.ModuleLoader(),
-			})
-			if err != nil {
-				log.Error("unable to evaluate JQ filter",
-					slog.String("filter", q), slog.Any("error", err))
-			} else {
-				if err := json.Unmarshal([]byte(s), &tmp); err != nil {
-					return err
-				}
-			}
+		tmp, err = applyFilter(ctx, opts, tmp)
+		if err != nil {
+			return err
 		}
 
-		got, ok := opts.out[opts.key]
-		if !ok {
-			opts.out[opts.key] = tmp
-			return nil
-		}
+		accumulate(opts.out, opts.key, tmp)
+
+		return nil
+	}
+}
+
+// applyFilter evaluates the local filter (if any) on the API result,
+// exposed under the API name together with the current slice.
+func applyFilter(ctx context.Context, opts jsonHandlerOptions, tmp any) (any, error) {
+	if opts.filter == nil {
+		return tmp, nil
+	}
+
+	log := xcontext.Logger(ctx)
+
+	pig := map[string]any{
+		opts.key: tmp,
+	}
+	if opts.slice != nil {
+		pig["slice"] = opts.slice
+	} else if si, ok := opts.out["slice"]; ok {
+		pig["slice"] = si
+	}
+
+	q := ptr.Deref(opts.filter, "")
+	log.Debug("found local filter on api result", slog.String("filter", q))
+	s, err := jqutil.Eval(context.TODO(), jqutil.EvalOptions{
+		Query: q, Data: pig,
+		ModuleLoader: jqsupport.ModuleLoader(),
+	})
+	if err != nil {
+		log.Error("unable to evaluate JQ filter",
+			slog.String("filter", q), slog.Any("error", err))
+		return tmp, nil
+	}
+
+	if err := json.Unmarshal([]byte(s), &tmp); err != nil {
+		return nil, err
+	}
+
+	return tmp, nil
+}
 
-		switch existingSlice := got.(type) {
+// accumulate stores the value under the given key: the first value
+// is stored as is, the next ones are collected into a slice.
+func accumulate(out map[string]any, key string, tmp any) {
+	got, ok := out[key]
+	if !ok {
+		out[key] = tmp
+		return
+	}
+
+	switch existingSlice := got.(type) {
+	case []any:
+		if v := wrapAsSlice(tmp); len(v) > 0 {
+			out[key] = append(existingSlice, v...)
+		}
+	default:
+		switch v := tmp.(type) {
 		case []any:
-			if v := wrapAsSlice(tmp); len(v) > 0 {
-				opts.out[opts.key] = append(existingSlice, v...)
-			}
+			all := []any{got}
+			all = append(all, v...)
+			out[key] = all
 		default:
-			opts.out[opts.key] = []any{got, tmp}
-
-			switch v := tmp.(type) {
-			case []any:
-				all := []any{got}
-				all = append(all, v...)
-				opts.out[opts.key] = all
-			default:
-				opts.out[opts.key] = []any{got, v}
-			}
+			out[key] = []any{got, v}
 		}
-
-		return nil
 	}
 }
 
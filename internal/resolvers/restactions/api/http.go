@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/plumbing/http/util"
+	"github.com/krateoplatformops/plumbing/ptr"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/fixtures"
+	"github.com/krateoplatformops/snowplow/internal/signing"
+)
+
+const maxUnstructuredResponseTextBytes = 2048
+
+// httpOptions describes a single HTTP call.
+type httpOptions struct {
+	call httpcall.RequestOptions
+	// handler consumes the body of successful responses.
+	handler func(*http.Response) error
+	// noRetry disables the HTTP client own retries
+	// (i.e. when the API declares a retry policy).
+	noRetry bool
+	// received, if not nil, is set to the number of bytes read from the response body.
+	received *int64
+	// transport, if not nil, is set to the error of a request that got no response.
+	transport *error
+	// signer, if not nil, signs the request once all the headers are set.
+	signer signing.Signer
+	// secrets are never written in the recorded fixtures.
+	secrets secretValues
+}
+
+// countingReader counts the bytes read from the wrapped reader.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// httpDo performs the HTTP call like httpcall.Do does, but it does not
+// restrict the response content type: the handler receives the whole
+// response, headers included.
+func httpDo(ctx context.Context, opts httpOptions) *response.Status {
+	call := opts.call
+
+	uri := strings.TrimSuffix(call.Endpoint.ServerURL, "/")
+	if len(call.Path) > 0 {
+		uri = fmt.Sprintf("%s/%s", uri, strings.TrimPrefix(call.Path, "/"))
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return response.New(http.StatusInternalServerError, err)
+	}
+
+	verb := ptr.Deref(call.Verb, http.MethodGet)
+
+	var body io.Reader
+	payload := ptr.Deref(call.Payload, "")
+	if len(payload) > 0 {
+		body = strings.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, verb, u.String(), body)
+	if err != nil {
+		return response.New(http.StatusInternalServerError, err)
+	}
+
+	headers := append([]string{}, call.Headers...)
+	// Additional headers for AWS Signature 4 algorithm
+	if call.Endpoint.HasAwsAuth() {
+		aws, _, _, _, _, _ := httpcall.ComputeAwsHeaders(call.Endpoint, &call.RequestInfo)
+		headers = append(headers, aws...)
+		headers = append(headers, xcontext.LabelKrateoTraceId+":"+xcontext.TraceId(ctx, true))
+		// Set all headers to lower case for AWS signature
+		for i := range headers {
+			key, val, _ := strings.Cut(headers[i], ":")
+			headers[i] = strings.ToLower(strings.TrimSpace(key)) + ":" + strings.TrimSpace(val)
+		}
+		sort.Strings(headers)
+	} else {
+		req.Header.Set(xcontext.LabelKrateoTraceId, xcontext.TraceId(ctx, true))
+	}
+
+	for _, el := range headers {
+		idx := strings.Index(el, ":")
+		if idx <= 0 {
+			continue
+		}
+		req.Header.Set(el[:idx], strings.TrimSpace(el[idx+1:]))
+	}
+
+	if opts.signer != nil {
+		var dat []byte
+		if len(payload) > 0 {
+			dat = []byte(payload)
+		}
+		if err := opts.signer.Sign(req, dat); err != nil {
+			return response.New(http.StatusInternalServerError,
+				fmt.Errorf("unable to sign request: %w", err))
+		}
+	}
+
+	cli, err := httpcall.HTTPClientForEndpoint(call.Endpoint, &call.RequestInfo)
+	if err != nil {
+		return response.New(http.StatusInternalServerError,
+			fmt.Errorf("unable to create HTTP Client for endpoint: %w", err))
+	}
+
+	if sess := fixtures.FromContext(ctx); sess != nil {
+		cli.Transport = sess.Transport(cli.Transport, opts.secrets.restore)
+	}
+
+	var res *http.Response
+	if opts.noRetry {
+		res, err = cli.Do(req)
+	} else {
+		res, err = util.NewRetryClient(cli).Do(req)
+	}
+	if err != nil {
+		if opts.transport != nil {
+			*opts.transport = err
+		}
+		return response.New(http.StatusInternalServerError, err)
+	}
+	defer res.Body.Close()
+
+	counter := &countingReader{ReadCloser: res.Body}
+	res.Body = counter
+	if opts.received != nil {
+		defer func() { *opts.received = counter.n }()
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return failureStatus(res)
+	}
+
+	if opts.handler != nil {
+		if err := opts.handler(res); err != nil {
+			return response.New(http.StatusInternalServerError, err)
+		}
+		return response.New(http.StatusOK, nil)
+	}
+
+	return response.New(http.StatusNoContent, nil)
+}
+
+// failureStatus converts an error response into a failure status:
+// the body is used as is when it is already a status object.
+func failureStatus(res *http.Response) *response.Status {
+	dat, err := io.ReadAll(io.LimitReader(res.Body, maxUnstructuredResponseTextBytes))
+	if err != nil {
+		return response.New(http.StatusInternalServerError, err)
+	}
+
+	status := &response.Status{}
+	if err := json.Unmarshal(dat, status); err != nil || status.Kind != "Status" {
+		status = response.New(res.StatusCode, fmt.Errorf("%s", string(dat)))
+	}
+
+	if status.Code == 0 {
+		status.Code = res.StatusCode
+	}
+	status.Status = response.StatusFailure
+
+	return status
+}
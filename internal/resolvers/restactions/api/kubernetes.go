@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/fixtures"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// resolveKubernetes resolves an API step reading Kubernetes objects
+// with the credentials of the current user.
+func resolveKubernetes(ctx context.Context, opts stepOptions) (res stepResult) {
+	log := xcontext.Logger(ctx)
+
+	id := opts.api.Name
+	errorKey := ptr.Deref(opts.api.ErrorKey, "error")
+	continueOnError := ptr.Deref(opts.api.ContinueOnError, false)
+
+	res.out = map[string]any{}
+
+	fail := func(status *response.Status) bool {
+		log.Error("unable to resolve kubernetes objects", slog.String("name", id),
+			slog.Int("code", status.Code), slog.String("error", status.Message))
+
+		storeFailure(log, res.out, errorKey, status)
+		res.fail(status, continueOnError)
+		return res.halt
+	}
+
+	// Same credentials used for the APIs without endpointRef
+	ep, err := opts.mapper.resolveOne(ctx, nil)
+	if err != nil {
+		fail(response.New(http.StatusUnauthorized, err))
+		return
+	}
+	if opts.verbose {
+		ep.Debug = opts.verbose
+	}
+
+	rc, err := dynamic.ClientConfig(ctx, ep)
+	if err != nil {
+		fail(response.New(http.StatusInternalServerError, err))
+		return
+	}
+
+	var cli dynamic.Client
+	if sess := fixtures.FromContext(ctx); sess != nil {
+		// the config may be shared with other resolutions
+		rc = rest.CopyConfig(rc)
+		rc.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return sess.Transport(rt, nil)
+		})
+		cli, err = dynamic.NewClient(rc)
+	} else {
+		cli, err = dynamic.ClientFor(ctx, rc)
+	}
+	if err != nil {
+		fail(response.New(http.StatusInternalServerError, err))
+		return
+	}
+
+	m := newMerger(id, opts.api.Merge)
+	m.init(res.out)
+
+	for _, ds := range iterationSources(log, opts.api, opts.dict) {
+		sel := evalKubernetesResource(opts.api.Kubernetes, ds)
+
+		start := time.Now()
+		got, status := getKubernetesObjects(ctx, cli, sel)
+		traceKubernetes(ctx, opts.api, sel, status, start, got)
+		if status != nil {
+			if fail(status) {
+				return
+			}
+			continue
+		}
+
+		tmp, err := applyFilter(ctx, jsonHandlerOptions{
+			key: id, out: res.out, slice: opts.dict["slice"], filter: opts.api.Filter,
+		}, got)
+		if err != nil {
+			if fail(response.New(http.StatusInternalServerError, err)) {
+				return
+			}
+			continue
+		}
+		if err := m.add(res.out, tmp); err != nil {
+			if fail(response.New(http.StatusInternalServerError,
+				fmt.Errorf("unable to merge api result: %w", err))) {
+				return
+			}
+		}
+	}
+
+	log.Info("api successfully resolved", slog.String("name", id),
+		slog.String("apiVersion", opts.api.Kubernetes.APIVersion),
+		slog.String("resource", opts.api.Kubernetes.Resource))
+
+	return
+}
+
+// traceKubernetes records the Kubernetes request, if tracing is enabled.
+func traceKubernetes(ctx context.Context, in *templates.API, sel templates.KubernetesResource, status *response.Status, start time.Time, got any) {
+	rec := trace.FromContext(ctx)
+	if rec == nil {
+		return
+	}
+
+	path := fmt.Sprintf("%s/%s", sel.APIVersion, sel.Resource)
+	if len(sel.Namespace) > 0 {
+		path = fmt.Sprintf("%s/namespaces/%s/%s", sel.APIVersion, sel.Namespace, sel.Resource)
+	}
+	verb := "LIST"
+	if len(sel.Name) > 0 {
+		path, verb = path+"/"+sel.Name, http.MethodGet
+	}
+
+	el := trace.Call{
+		API: in.Name, RESTAction: trace.Scope(ctx), Kind: "kubernetes",
+		Verb: verb, Path: path,
+		Status: http.StatusOK, Duration: trace.Duration(start), Key: in.Name,
+	}
+	if status != nil {
+		el.Status, el.Error = status.Code, status.Message
+		el.Key = ptr.Deref(in.ErrorKey, "error")
+	} else if dat, err := json.Marshal(got); err == nil {
+		el.Bytes = int64(len(dat))
+	}
+
+	rec.AddCall(el)
+}
+
+// evalKubernetesResource evaluates the JQ expressions of the selector fields.
+func evalKubernetesResource(in *templates.KubernetesResource, ds source) templates.KubernetesResource {
+	return templates.KubernetesResource{
+		APIVersion:    evalJQ(in.APIVersion, ds),
+		Resource:      evalJQ(in.Resource, ds),
+		Namespace:     evalJQ(in.Namespace, ds),
+		Name:          evalJQ(in.Name, ds),
+		LabelSelector: evalJQ(in.LabelSelector, ds),
+		FieldSelector: evalJQ(in.FieldSelector, ds),
+	}
+}
+
+// getKubernetesObjects gets the named object or lists the selected ones.
+func getKubernetesObjects(ctx context.Context, cli dynamic.Client, sel templates.KubernetesResource) (any, *response.Status) {
+	gv, err := schema.ParseGroupVersion(sel.APIVersion)
+	if err != nil {
+		return nil, response.New(http.StatusBadRequest, err)
+	}
+	if len(sel.Resource) == 0 {
+		return nil, response.New(http.StatusBadRequest, fmt.Errorf("missing kubernetes resource"))
+	}
+
+	opts := dynamic.Options{
+		Namespace:     sel.Namespace,
+		GVR:           gv.WithResource(sel.Resource),
+		LabelSelector: sel.LabelSelector,
+		FieldSelector: sel.FieldSelector,
+	}
+
+	var obj any
+	if len(sel.Name) > 0 {
+		obj, err = cli.Get(ctx, sel.Name, opts)
+	} else {
+		obj, err = cli.List(ctx, opts)
+	}
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	// convert to plain JSON values (i.e. int64 are not JQ friendly)
+	dat, err := json.Marshal(obj)
+	if err != nil {
+		return nil, response.New(http.StatusInternalServerError, err)
+	}
+
+	var res any
+	if err := json.Unmarshal(dat, &res); err != nil {
+		return nil, response.New(http.StatusInternalServerError, err)
+	}
+
+	return res, nil
+}
+
+// statusFromError converts the error into a failure status,
+// preserving the code of the Kubernetes API errors.
+func statusFromError(err error) *response.Status {
+	code := http.StatusInternalServerError
+	var el apierrors.APIStatus
+	if errors.As(err, &el) && el.Status().Code > 0 {
+		code = int(el.Status().Code)
+	} else if meta.IsNoMatchError(err) {
+		code = http.StatusNotFound
+	}
+
+	res := response.New(code, err)
+	res.Status = response.StatusFailure
+	return res
+}
+
+// recordRead records the objects read by a GET of the internal endpoint, as
+// the dynamic client does, so that the widget streams watch them too.
+func recordRead(ctx context.Context, opts callOptions, call httpcall.RequestOptions, status *response.Status, body any) {
+	if !opts.internal || ptr.Deref(call.Verb, http.MethodGet) != http.MethodGet {
+		return
+	}
+
+	reads := dynamic.ReadsFromContext(ctx)
+	if reads == nil {
+		return
+	}
+
+	el, ok := dynamic.ReadOfPath(call.Path)
+	if !ok {
+		return
+	}
+
+	switch {
+	case !isFailure(status):
+		var rv string
+		if obj, ok := body.(map[string]any); ok {
+			rv, _, _ = unstructured.NestedString(obj, "metadata", "resourceVersion")
+		}
+		reads.Add(el, rv)
+	case status.Code == http.StatusNotFound && len(el.Name) > 0:
+		// the object may be created later
+		reads.Add(el, "")
+	}
+}
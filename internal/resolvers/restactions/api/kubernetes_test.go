@@ -0,0 +1,111 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestGetKubernetesObjects(t *testing.T) {
+	cli := &fakeDynamicClient{
+		objects: []map[string]any{
+			{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{
+				"name": "web", "namespace": "demo", "generation": int64(2),
+			}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		sel    templates.KubernetesResource
+		expect any
+		code   int
+	}{
+		{
+			name: "get",
+			sel:  templates.KubernetesResource{APIVersion: "v1", Resource: "pods", Namespace: "demo", Name: "web"},
+			expect: map[string]any{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{
+				"name": "web", "namespace": "demo", "generation": 2,
+			}},
+		},
+		{
+			name: "list",
+			sel: templates.KubernetesResource{
+				APIVersion: "v1", Resource: "pods", Namespace: "demo", LabelSelector: "app=web",
+			},
+			expect: map[string]any{"apiVersion": "v1", "kind": "List", "items": []any{
+				map[string]any{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]any{
+					"name": "web", "namespace": "demo", "generation": 2,
+				}},
+			}},
+		},
+		{
+			name: "not found",
+			sel:  templates.KubernetesResource{APIVersion: "v1", Resource: "pods", Namespace: "demo", Name: "db"},
+			code: http.StatusNotFound,
+		},
+		{
+			name: "invalid api version",
+			sel:  templates.KubernetesResource{APIVersion: "a/b/c", Resource: "pods"},
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, status := getKubernetesObjects(context.Background(), cli, tc.sel)
+			if tc.code != 0 {
+				if status == nil || status.Code != tc.code {
+					t.Fatalf("expected failure with code %d, got: %v", tc.code, status)
+				}
+				return
+			}
+
+			if status != nil {
+				t.Fatalf("unexpected failure: %v", status)
+			}
+			if !deepEqual(got, tc.expect) {
+				t.Errorf("expected %v, got %v", tc.expect, got)
+			}
+		})
+	}
+
+	if cli.opts.LabelSelector != "app=web" {
+		t.Errorf("expected label selector to be forwarded, got: %v", cli.opts)
+	}
+}
+
+type fakeDynamicClient struct {
+	dynamic.Client
+	objects []map[string]any
+	opts    dynamic.Options
+}
+
+func (c *fakeDynamicClient) Get(_ context.Context, name string, opts dynamic.Options) (*unstructured.Unstructured, error) {
+	for _, el := range c.objects {
+		obj := &unstructured.Unstructured{Object: el}
+		if obj.GetName() == name && obj.GetNamespace() == opts.Namespace {
+			return obj, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: opts.GVR.Resource}, name)
+}
+
+func (c *fakeDynamicClient) List(_ context.Context, opts dynamic.Options) (*unstructured.UnstructuredList, error) {
+	c.opts = opts
+
+	res := &unstructured.UnstructuredList{Object: map[string]any{"apiVersion": "v1", "kind": "List"}}
+	for _, el := range c.objects {
+		res.Items = append(res.Items, unstructured.Unstructured{Object: el})
+	}
+	return res, nil
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/krateoplatformops/plumbing/jqutil"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	jqsupport "github.com/krateoplatformops/snowplow/internal/support/jq"
+)
+
+// merger stores the results of the API calls under the API key following
+// the merge policy; with no policy the results are accumulated.
+type merger struct {
+	key    string
+	policy *templates.MergePolicy
+	// index is the position of each item key (keyed strategy only).
+	index map[string]int
+}
+
+func newMerger(key string, policy *templates.MergePolicy) *merger {
+	return &merger{key: key, policy: policy, index: map[string]int{}}
+}
+
+// init stores an empty array for the strategies always producing
+// an array, so that the API key is set even if no call is performed.
+func (m *merger) init(out map[string]any) {
+	if m.policy == nil {
+		return
+	}
+
+	switch m.policy.Strategy {
+	case templates.MergeArray, templates.MergeConcat, templates.MergeKeyed:
+		if _, ok := out[m.key]; !ok {
+			out[m.key] = []any{}
+		}
+	}
+}
+
+// add merges the value with the one stored under the API key.
+func (m *merger) add(out map[string]any, val any) error {
+	if m.policy == nil {
+		accumulate(out, m.key, val)
+		return nil
+	}
+
+	switch m.policy.Strategy {
+	case templates.MergeArray:
+		all, _ := out[m.key].([]any)
+		out[m.key] = append(all, val)
+
+	case templates.MergeReplace:
+		out[m.key] = val
+
+	case templates.MergeConcat:
+		all, _ := out[m.key].([]any)
+		if all == nil {
+			all = []any{}
+		}
+		if val != nil {
+			all = append(all, wrapAsSlice(val)...)
+		}
+		out[m.key] = all
+
+	case templates.MergeDeepMerge:
+		out[m.key] = deepMerge(out[m.key], val)
+
+	case templates.MergeKeyed:
+		return m.addKeyed(out, val)
+
+	default:
+		return fmt.Errorf("unknown merge strategy %q", m.policy.Strategy)
+	}
+
+	return nil
+}
+
+// addKeyed appends the items to the stored array, deep merging
+// the ones whose key has already been seen.
+func (m *merger) addKeyed(out map[string]any, val any) error {
+	all, _ := out[m.key].([]any)
+	if all == nil {
+		all = []any{}
+	}
+	if val == nil {
+		out[m.key] = all
+		return nil
+	}
+
+	q := ptr.Deref(m.policy.Key, "")
+	if len(q) == 0 {
+		return fmt.Errorf("keyed merge requires the key expression")
+	}
+
+	for _, el := range wrapAsSlice(val) {
+		s, err := jqutil.Eval(context.TODO(), jqutil.EvalOptions{
+			Query: q, Data: el,
+			ModuleLoader: jqsupport.ModuleLoader(),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to evaluate merge key: %w", err)
+		}
+
+		// items without key are never merged
+		k := strings.TrimSpace(s)
+		if len(k) == 0 || k == "null" {
+			all = append(all, el)
+			continue
+		}
+
+		if i, ok := m.index[k]; ok {
+			all[i] = deepMerge(all[i], el)
+			continue
+		}
+		m.index[k] = len(all)
+		all = append(all, el)
+	}
+
+	out[m.key] = all
+	return nil
+}
+
+// deepMerge merges the src object into a copy of the dst object
+// recursively; any other src value replaces dst.
+func deepMerge(dst, src any) any {
+	a, ok := dst.(map[string]any)
+	if !ok {
+		return src
+	}
+	b, ok := src.(map[string]any)
+	if !ok {
+		return src
+	}
+
+	res := make(map[string]any, len(a)+len(b))
+	for k, v := range a {
+		res[k] = v
+	}
+	for k, v := range b {
+		res[k] = deepMerge(res[k], v)
+	}
+	return res
+}
@@ -0,0 +1,140 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/endpoints"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+)
+
+func TestMerger(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *templates.MergePolicy
+		values []any
+		want   any
+	}{
+		{
+			name:   "legacy single value",
+			values: []any{map[string]any{"id": 1.0}},
+			want:   map[string]any{"id": 1.0},
+		},
+		{
+			name:   "legacy two values",
+			values: []any{map[string]any{"id": 1.0}, map[string]any{"id": 2.0}},
+			want:   []any{map[string]any{"id": 1.0}, map[string]any{"id": 2.0}},
+		},
+		{
+			name:   "array no values",
+			policy: &templates.MergePolicy{Strategy: templates.MergeArray},
+			want:   []any{},
+		},
+		{
+			name:   "array single value",
+			policy: &templates.MergePolicy{Strategy: templates.MergeArray},
+			values: []any{[]any{1.0, 2.0}},
+			want:   []any{[]any{1.0, 2.0}},
+		},
+		{
+			name:   "replace",
+			policy: &templates.MergePolicy{Strategy: templates.MergeReplace},
+			values: []any{"a", "b"},
+			want:   "b",
+		},
+		{
+			name:   "concat",
+			policy: &templates.MergePolicy{Strategy: templates.MergeConcat},
+			values: []any{[]any{1.0, 2.0}, 3.0, nil, []any{4.0}},
+			want:   []any{1.0, 2.0, 3.0, 4.0},
+		},
+		{
+			name:   "deep merge",
+			policy: &templates.MergePolicy{Strategy: templates.MergeDeepMerge},
+			values: []any{
+				map[string]any{"a": map[string]any{"x": 1.0, "y": 1.0}, "b": []any{1.0}},
+				map[string]any{"a": map[string]any{"y": 2.0}, "b": []any{2.0}, "c": true},
+			},
+			want: map[string]any{"a": map[string]any{"x": 1.0, "y": 2.0}, "b": []any{2.0}, "c": true},
+		},
+		{
+			name:   "keyed",
+			policy: &templates.MergePolicy{Strategy: templates.MergeKeyed, Key: ptr.To(".id")},
+			values: []any{
+				[]any{map[string]any{"id": "a", "v": 1.0}, map[string]any{"id": "b", "v": 1.0}},
+				map[string]any{"id": "a", "w": 2.0},
+				[]any{map[string]any{"v": 3.0}, map[string]any{"v": 4.0}},
+			},
+			want: []any{
+				map[string]any{"id": "a", "v": 1.0, "w": 2.0},
+				map[string]any{"id": "b", "v": 1.0},
+				map[string]any{"v": 3.0},
+				map[string]any{"v": 4.0},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out := map[string]any{}
+
+			m := newMerger("test", tc.policy)
+			m.init(out)
+			for _, el := range tc.values {
+				if err := m.add(out, el); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !deepEqual(out["test"], tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, out["test"])
+			}
+		})
+	}
+}
+
+func TestMergerKeyedWithoutKey(t *testing.T) {
+	m := newMerger("test", &templates.MergePolicy{Strategy: templates.MergeKeyed})
+	if err := m.add(map[string]any{}, []any{1.0}); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestRunCallsMerge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "` + r.URL.Query().Get("name") + `"}`))
+	}))
+	defer srv.Close()
+
+	call := func(name string) httpcall.RequestOptions {
+		return httpcall.RequestOptions{
+			RequestInfo: httpcall.RequestInfo{Path: "/items?name=" + name},
+			Endpoint:    &endpoints.Endpoint{ServerURL: srv.URL},
+		}
+	}
+
+	policy := &templates.MergePolicy{Strategy: templates.MergeArray}
+	for _, names := range [][]string{{"a"}, {"a", "b"}} {
+		calls := []httpcall.RequestOptions{}
+		for _, el := range names {
+			calls = append(calls, call(el))
+		}
+
+		res := runCalls(context.Background(), runOptions{
+			id: "items", calls: calls, merge: policy, maxConcurrency: 2,
+		})
+
+		got, ok := res.out["items"].([]any)
+		if !ok || len(got) != len(names) {
+			t.Fatalf("expected an array of %d items, got %v", len(names), res.out["items"])
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/endpoints"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/http/response"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Endpoint secret keys describing how to get an OAuth2 access token.
+const (
+	oauth2TokenURLLabel     = "oauth2-token-url"
+	oauth2ClientIDLabel     = "oauth2-client-id"
+	oauth2ClientSecretLabel = "oauth2-client-secret"
+	oauth2ScopesLabel       = "oauth2-scopes"
+	oauth2AudienceLabel     = "oauth2-audience"
+	oauth2GrantTypeLabel    = "oauth2-grant-type"
+	oauth2AuthStyleLabel    = "oauth2-auth-style"
+)
+
+const (
+	grantClientCredentials = "client_credentials"
+	grantTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	tokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+	// defaultTokenLifetime is used when the token response has no expires_in.
+	defaultTokenLifetime = 5 * time.Minute
+	// maxTokenExpiryDelta is the maximum advance with which a cached token is refreshed.
+	maxTokenExpiryDelta = time.Minute
+	// maxCachedTokens bounds the cache (a token per user with token exchange).
+	maxCachedTokens = 1024
+)
+
+// oauth2Config describes how to get the access token of an endpoint.
+type oauth2Config struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	audience     string
+	grantType    string
+	// authInHeader sends the client credentials with basic authentication
+	// instead of the form parameters.
+	authInHeader bool
+	// transport holds the TLS and proxy settings of the endpoint.
+	transport endpoints.Endpoint
+}
+
+// oauth2FromSecret returns the OAuth2 configuration of the endpoint secret,
+// nil if the secret does not declare a token URL.
+func oauth2FromSecret(sec *corev1.Secret, ep endpoints.Endpoint) (*oauth2Config, error) {
+	tokenURL := string(sec.Data[oauth2TokenURLLabel])
+	if len(tokenURL) == 0 {
+		return nil, nil
+	}
+
+	res := &oauth2Config{
+		tokenURL:     tokenURL,
+		clientID:     string(sec.Data[oauth2ClientIDLabel]),
+		clientSecret: string(sec.Data[oauth2ClientSecretLabel]),
+		scopes:       strings.FieldsFunc(string(sec.Data[oauth2ScopesLabel]), isScopeSeparator),
+		audience:     string(sec.Data[oauth2AudienceLabel]),
+		grantType:    grantClientCredentials,
+		authInHeader: string(sec.Data[oauth2AuthStyleLabel]) == "header",
+		transport: endpoints.Endpoint{
+			ProxyURL:                 ep.ProxyURL,
+			CertificateAuthorityData: ep.CertificateAuthorityData,
+			Insecure:                 ep.Insecure,
+		},
+	}
+
+	switch v := string(sec.Data[oauth2GrantTypeLabel]); v {
+	case "", grantClientCredentials:
+	case "token-exchange", grantTokenExchange:
+		res.grantType = grantTokenExchange
+	default:
+		return nil, fmt.Errorf("unsupported oauth2 grant type %q", v)
+	}
+
+	if len(res.clientID) == 0 {
+		return nil, fmt.Errorf("missed required attribute for oauth2 endpoint: %s", oauth2ClientIDLabel)
+	}
+
+	return res, nil
+}
+
+func isScopeSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '\n'
+}
+
+// cacheKey identifies the token: it changes with the endpoint
+// configuration and, for token exchange, with the subject token.
+func (c *oauth2Config) cacheKey(subject string) string {
+	h := sha256.New()
+	for _, el := range []string{
+		c.tokenURL, c.clientID, c.clientSecret, c.audience, c.grantType,
+		strings.Join(c.scopes, " "), subject,
+	} {
+		h.Write([]byte(el))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type cachedToken struct {
+	value   string
+	expires time.Time
+}
+
+// tokenCall is a token request in flight, shared by the concurrent
+// resolutions needing the same token.
+type tokenCall struct {
+	done  chan struct{}
+	value string
+	err   error
+	// canceled is true when the request failed because the
+	// context of the resolution performing it is done.
+	canceled bool
+}
+
+// tokenCache holds the access tokens until shortly before they expire;
+// it is safe for concurrent use. At most one request per token is in flight.
+type tokenCache struct {
+	mu      sync.Mutex
+	items   map[string]cachedToken
+	pending map[string]*tokenCall
+	// size is the maximum number of cached tokens.
+	size int
+	now  func() time.Time
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{
+		items:   map[string]cachedToken{},
+		pending: map[string]*tokenCall{},
+		size:    maxCachedTokens,
+		now:     time.Now,
+	}
+}
+
+// tokens is the access tokens cache shared by all the resolutions.
+var tokens = newTokenCache()
+
+// token returns the cached access token, or requests a new one.
+// The subject token is only used by the token exchange grant.
+func (c *tokenCache) token(ctx context.Context, cfg *oauth2Config, subject string) (string, error) {
+	if cfg.grantType != grantTokenExchange {
+		subject = ""
+	} else if len(subject) == 0 {
+		return "", fmt.Errorf("oauth2 token exchange requires the user access token")
+	}
+
+	key := cfg.cacheKey(subject)
+
+	for {
+		c.mu.Lock()
+		el, ok := c.items[key]
+		if ok && c.now().Before(el.expires) {
+			c.mu.Unlock()
+			return el.value, nil
+		}
+		call, pending := c.pending[key]
+		if !pending {
+			call = &tokenCall{done: make(chan struct{})}
+			c.pending[key] = call
+		}
+		c.mu.Unlock()
+
+		if !pending {
+			return c.fetch(ctx, key, call, cfg, subject)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-call.done:
+		}
+
+		// the request of a canceled resolution does not fail the others
+		if !call.canceled {
+			return call.value, call.err
+		}
+	}
+}
+
+// fetch performs the token request shared through the call.
+func (c *tokenCache) fetch(ctx context.Context, key string, call *tokenCall, cfg *oauth2Config, subject string) (string, error) {
+	value, lifetime, err := fetchToken(ctx, cfg, subject)
+	call.value, call.err = value, err
+	call.canceled = err != nil && ctx.Err() != nil
+
+	c.mu.Lock()
+	delete(c.pending, key)
+	if err == nil {
+		c.set(key, cachedToken{value: value, expires: c.now().Add(lifetime - expiryDelta(lifetime))})
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return value, err
+}
+
+// set stores the token, c.mu must be held. The expired tokens are evicted;
+// when the cache is still full, the token expiring first makes room.
+func (c *tokenCache) set(key string, el cachedToken) {
+	now := c.now()
+	for k, v := range c.items {
+		if !now.Before(v.expires) {
+			delete(c.items, k)
+		}
+	}
+
+	if _, ok := c.items[key]; !ok && len(c.items) >= max(c.size, 1) {
+		var first string
+		for k, v := range c.items {
+			if len(first) == 0 || v.expires.Before(c.items[first].expires) {
+				first = k
+			}
+		}
+		delete(c.items, first)
+	}
+
+	c.items[key] = el
+}
+
+// invalidate discards the cached token (i.e. when it has been rejected).
+func (c *tokenCache) invalidate(cfg *oauth2Config, subject string) {
+	if cfg.grantType != grantTokenExchange {
+		subject = ""
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, cfg.cacheKey(subject))
+}
+
+// expiryDelta is the advance with which a token is refreshed: 10% of
+// its lifetime, at most maxTokenExpiryDelta.
+func expiryDelta(lifetime time.Duration) time.Duration {
+	return min(lifetime/10, maxTokenExpiryDelta)
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   any    `json:"expires_in"`
+}
+
+// fetchToken requests a new access token; returns the token and its lifetime.
+func fetchToken(ctx context.Context, cfg *oauth2Config, subject string) (string, time.Duration, error) {
+	form := url.Values{}
+	form.Set("grant_type", cfg.grantType)
+	if len(cfg.scopes) > 0 {
+		form.Set("scope", strings.Join(cfg.scopes, " "))
+	}
+	if len(cfg.audience) > 0 {
+		form.Set("audience", cfg.audience)
+	}
+	if cfg.grantType == grantTokenExchange {
+		form.Set("subject_token", subject)
+		form.Set("subject_token_type", tokenTypeAccessToken)
+		form.Set("requested_token_type", tokenTypeAccessToken)
+	}
+	if !cfg.authInHeader {
+		form.Set("client_id", cfg.clientID)
+		if len(cfg.clientSecret) > 0 {
+			form.Set("client_secret", cfg.clientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.authInHeader {
+		req.SetBasicAuth(url.QueryEscape(cfg.clientID), url.QueryEscape(cfg.clientSecret))
+	}
+
+	transport := cfg.transport
+	transport.ServerURL = cfg.tokenURL
+	cli, err := httpcall.HTTPClientForEndpoint(&transport, nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("unable to create HTTP Client for token endpoint: %w", err)
+	}
+
+	res, err := cli.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("unable to request oauth2 token: %w", err)
+	}
+	defer res.Body.Close()
+
+	dat, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", 0, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("oauth2 token request failed (%d): %s",
+			res.StatusCode, string(dat[:min(len(dat), maxUnstructuredResponseTextBytes)]))
+	}
+
+	var tok tokenResponse
+	if err := json.Unmarshal(dat, &tok); err != nil {
+		return "", 0, fmt.Errorf("unable to decode oauth2 token response: %w", err)
+	}
+	if len(tok.AccessToken) == 0 {
+		return "", 0, fmt.Errorf("oauth2 token response has no access_token")
+	}
+
+	return tok.AccessToken, tokenLifetime(tok.ExpiresIn), nil
+}
+
+// tokenLifetime parses expires_in, sent either as a number or as a string.
+func tokenLifetime(v any) time.Duration {
+	var secs int64
+	switch val := v.(type) {
+	case float64:
+		secs = int64(val)
+	case string:
+		secs, _ = strconv.ParseInt(val, 10, 64)
+	}
+
+	if secs <= 0 {
+		return defaultTokenLifetime
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// withAccessToken returns the call with the endpoint bearer token set
+// to the (cached) OAuth2 access token; a failure status otherwise.
+func withAccessToken(ctx context.Context, cfg *oauth2Config, call httpcall.RequestOptions) (httpcall.RequestOptions, *response.Status) {
+	if cfg == nil {
+		return call, nil
+	}
+
+	subject, _ := xcontext.AccessToken(ctx)
+	tok, err := tokens.token(ctx, cfg, subject)
+	if err != nil {
+		status := response.New(http.StatusUnauthorized, fmt.Errorf("unable to get oauth2 access token: %w", err))
+		status.Status = response.StatusFailure
+		return call, status
+	}
+
+	ep := *call.Endpoint
+	ep.Token = tok
+	call.Endpoint = &ep
+	return call, nil
+}
@@ -0,0 +1,292 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/endpoints"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestOAuth2FromSecret(t *testing.T) {
+	sec := &corev1.Secret{Data: map[string][]byte{
+		"server-url":            []byte("https://api.example.com"),
+		oauth2TokenURLLabel:     []byte("https://login.example.com/token"),
+		oauth2ClientIDLabel:     []byte("app"),
+		oauth2ClientSecretLabel: []byte("secret"),
+		oauth2ScopesLabel:       []byte("read, write"),
+		oauth2GrantTypeLabel:    []byte("token-exchange"),
+	}}
+
+	got, err := oauth2FromSecret(sec, endpoints.Endpoint{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.grantType != grantTokenExchange || len(got.scopes) != 2 || !got.transport.Insecure {
+		t.Errorf("unexpected config: %+v", got)
+	}
+
+	got, err = oauth2FromSecret(&corev1.Secret{}, endpoints.Endpoint{})
+	if err != nil || got != nil {
+		t.Errorf("expected no config, got %+v (%v)", got, err)
+	}
+
+	sec.Data[oauth2GrantTypeLabel] = []byte("password")
+	if _, err := oauth2FromSecret(sec, endpoints.Endpoint{}); err == nil {
+		t.Errorf("expected unsupported grant type error")
+	}
+}
+
+func TestTokenCache(t *testing.T) {
+	var issued atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_id") != "app" || r.Form.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		subject := r.Form.Get("subject_token")
+		if r.Form.Get("grant_type") == grantTokenExchange && len(subject) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "tok-%d%s", "token_type": "Bearer", "expires_in": 600}`, n, subject)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	cache := newTokenCache()
+	cache.now = func() time.Time { return now }
+
+	cfg := &oauth2Config{
+		tokenURL: srv.URL, clientID: "app", clientSecret: "secret",
+		grantType: grantClientCredentials,
+	}
+
+	for range 3 {
+		tok, err := cache.token(context.Background(), cfg, "ignored")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok != "tok-1" {
+			t.Fatalf("expected cached token, got %s", tok)
+		}
+	}
+
+	// refreshed one minute before the expiration
+	now = now.Add(9*time.Minute + time.Second)
+	if tok, _ := cache.token(context.Background(), cfg, ""); tok != "tok-2" {
+		t.Errorf("expected refreshed token, got %s", tok)
+	}
+
+	cache.invalidate(cfg, "")
+	if tok, _ := cache.token(context.Background(), cfg, ""); tok != "tok-3" {
+		t.Errorf("expected new token after invalidation, got %s", tok)
+	}
+
+	exchange := *cfg
+	exchange.grantType = grantTokenExchange
+	if tok, _ := cache.token(context.Background(), &exchange, "-alice"); tok != "tok-4-alice" {
+		t.Errorf("expected exchanged token, got %s", tok)
+	}
+	if tok, _ := cache.token(context.Background(), &exchange, "-bob"); tok != "tok-5-bob" {
+		t.Errorf("expected a token per subject, got %s", tok)
+	}
+	if _, err := cache.token(context.Background(), &exchange, ""); err == nil {
+		t.Errorf("expected error without subject token")
+	}
+
+	cfg.clientSecret = "wrong"
+	if _, err := cache.token(context.Background(), cfg, ""); err == nil {
+		t.Errorf("expected token request failure")
+	}
+}
+
+func TestTokenCacheEviction(t *testing.T) {
+	var issued atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "tok-%d-%s", "expires_in": 600}`, n, r.Form.Get("subject_token"))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	cache := newTokenCache()
+	cache.now = func() time.Time { return now }
+	cache.size = 2
+
+	cfg := &oauth2Config{tokenURL: srv.URL, clientID: "app", grantType: grantTokenExchange}
+	get := func(subject string) string {
+		tok, err := cache.token(context.Background(), cfg, subject)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	get("alice")
+	get("bob")
+
+	// the expired tokens are evicted when a new one is stored
+	now = now.Add(10 * time.Minute)
+	get("carol")
+	if got := len(cache.items); got != 1 {
+		t.Fatalf("expected the expired tokens to be evicted, got %d tokens", got)
+	}
+
+	// when full, the token expiring first makes room
+	now = now.Add(time.Second)
+	get("dave")
+	get("eve")
+	if got := len(cache.items); got != 2 {
+		t.Fatalf("expected at most 2 tokens, got %d", got)
+	}
+	if tok := get("eve"); tok != "tok-5-eve" {
+		t.Errorf("expected the cached token, got %s", tok)
+	}
+	if tok := get("carol"); tok != "tok-6-carol" {
+		t.Errorf("expected the evicted token to be requested again, got %s", tok)
+	}
+}
+
+// blockingTokenServer issues the tokens; the first request is held
+// until release is closed.
+func blockingTokenServer(issued *atomic.Int32) (srv *httptest.Server, started, release chan struct{}) {
+	started, release = make(chan struct{}, 1), make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := issued.Add(1)
+		if n == 1 {
+			started <- struct{}{}
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "tok-%d", "expires_in": 600}`, n)
+	}))
+	return
+}
+
+func TestTokenCacheConcurrentRequests(t *testing.T) {
+	t.Run("shared request", func(t *testing.T) {
+		var issued atomic.Int32
+		srv, started, release := blockingTokenServer(&issued)
+		defer srv.Close()
+
+		cfg := &oauth2Config{tokenURL: srv.URL, clientID: "app", grantType: grantClientCredentials}
+		cache := newTokenCache()
+
+		got := make(chan string, 10)
+		for range cap(got) {
+			go func() {
+				tok, err := cache.token(context.Background(), cfg, "")
+				if err != nil {
+					t.Error(err)
+				}
+				got <- tok
+			}()
+		}
+
+		<-started
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+
+		for range cap(got) {
+			if tok := <-got; tok != "tok-1" {
+				t.Errorf("expected the shared token, got %s", tok)
+			}
+		}
+		if n := issued.Load(); n != 1 {
+			t.Errorf("expected a single token request, got %d", n)
+		}
+	})
+
+	t.Run("canceled request", func(t *testing.T) {
+		var issued atomic.Int32
+		srv, started, release := blockingTokenServer(&issued)
+		defer srv.Close()
+		defer close(release)
+
+		cfg := &oauth2Config{tokenURL: srv.URL, clientID: "app", grantType: grantClientCredentials}
+		cache := newTokenCache()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		failed := make(chan error, 1)
+		go func() {
+			_, err := cache.token(ctx, cfg, "")
+			failed <- err
+		}()
+		<-started
+
+		got := make(chan string, 1)
+		go func() {
+			tok, err := cache.token(context.Background(), cfg, "")
+			if err != nil {
+				t.Error(err)
+			}
+			got <- tok
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		// the canceled resolution does not fail the waiting one
+		cancel()
+		if err := <-failed; err == nil {
+			t.Errorf("expected the canceled request to fail")
+		}
+		if tok := <-got; tok != "tok-2" {
+			t.Errorf("expected a new token request, got %s", tok)
+		}
+	})
+}
+
+func TestDoCallWithAccessToken(t *testing.T) {
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "t0k3n", "expires_in": "3600"}`)
+	}))
+	defer tokenSrv.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0k3n" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	ctx := xcontext.BuildContext(context.Background())
+	res := doCall(ctx, callOptions{
+		id: "test",
+		call: httpcall.RequestOptions{
+			RequestInfo: httpcall.RequestInfo{Path: "/"},
+			Endpoint:    &endpoints.Endpoint{ServerURL: srv.URL},
+		},
+		auth: &oauth2Config{
+			tokenURL: tokenSrv.URL, clientID: "app", grantType: grantClientCredentials,
+		},
+	})
+	if res.failed() {
+		t.Fatalf("unexpected failure: %v", res.status)
+	}
+
+	if !deepEqual(res.out["test"], map[string]any{"ok": true}) {
+		t.Errorf("unexpected result: %v", res.out)
+	}
+}
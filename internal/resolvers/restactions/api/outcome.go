@@ -0,0 +1,44 @@
+package api
+
+// OutcomeState is the final state of an API step.
+type OutcomeState string
+
+const (
+	OutcomeSuccess OutcomeState = "success"
+	OutcomeFailed  OutcomeState = "failed"
+	OutcomeSkipped OutcomeState = "skipped"
+)
+
+// Outcome describes how an API step has been resolved.
+type Outcome struct {
+	// API is the name of the API.
+	API   string       `json:"api"`
+	State OutcomeState `json:"state"`
+	// Code is the status code of the (first) failure.
+	Code int `json:"code,omitempty"`
+	// Message is the failure message or the reason why the API has been skipped.
+	Message string `json:"message,omitempty"`
+	// ContinueOnError reports whether the resolution went on despite the failure.
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+}
+
+// Failed returns the failed outcomes.
+func Failed(all []Outcome) []Outcome {
+	var res []Outcome
+	for _, el := range all {
+		if el.State == OutcomeFailed {
+			res = append(res, el)
+		}
+	}
+	return res
+}
+
+// Halted reports whether a failure, not allowed by continueOnError, stopped the resolution.
+func Halted(all []Outcome) bool {
+	for _, el := range all {
+		if el.State == OutcomeFailed && !el.ContinueOnError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/http/response"
+)
+
+func TestStepResultOutcome(t *testing.T) {
+	notFound := response.New(http.StatusNotFound, fmt.Errorf("not here"))
+	unavailable := response.New(http.StatusServiceUnavailable, fmt.Errorf("down"))
+
+	tests := []struct {
+		name   string
+		res    func() stepResult
+		expect Outcome
+	}{
+		{
+			name:   "success",
+			res:    func() stepResult { return stepResult{} },
+			expect: Outcome{API: "test", State: OutcomeSuccess},
+		},
+		{
+			name: "skipped",
+			res: func() stepResult {
+				return stepResult{skipped: true, reason: "when evaluated to false"}
+			},
+			expect: Outcome{API: "test", State: OutcomeSkipped, Message: "when evaluated to false"},
+		},
+		{
+			name: "failed",
+			res: func() stepResult {
+				var res stepResult
+				res.fail(notFound, false)
+				res.fail(unavailable, true)
+				return res
+			},
+			expect: Outcome{
+				API: "test", State: OutcomeFailed,
+				Code: http.StatusNotFound, Message: "not here",
+			},
+		},
+		{
+			name: "continue on error",
+			res: func() stepResult {
+				var res stepResult
+				res.fail(unavailable, true)
+				return res
+			},
+			expect: Outcome{
+				API: "test", State: OutcomeFailed,
+				Code: http.StatusServiceUnavailable, Message: "down",
+				ContinueOnError: true,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := tc.res()
+			if got := res.outcome("test"); got != tc.expect {
+				t.Errorf("expected %+v, got %+v", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestHalted(t *testing.T) {
+	all := []Outcome{
+		{API: "a", State: OutcomeSuccess},
+		{API: "b", State: OutcomeFailed, ContinueOnError: true},
+		{API: "c", State: OutcomeSkipped},
+	}
+	if Halted(all) {
+		t.Errorf("expected not halted")
+	}
+	if got := len(Failed(all)); got != 1 {
+		t.Errorf("expected 1 failed outcome, got %d", got)
+	}
+
+	all = append(all, Outcome{API: "d", State: OutcomeFailed})
+	if !Halted(all) {
+		t.Errorf("expected halted")
+	}
+}
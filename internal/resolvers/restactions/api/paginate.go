@@ -0,0 +1,284 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/plumbing/jqutil"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	jqsupport "github.com/krateoplatformops/snowplow/internal/support/jq"
+)
+
+const defaultMaxPages = 100
+
+// page is a single decoded page of a paginated response.
+type page struct {
+	body any
+	link string
+}
+
+// doPaginatedCall requests all the pages of the call, concatenates
+// their items and then applies the API filter on the whole list.
+func doPaginatedCall(ctx context.Context, opts callOptions) (res callResult) {
+	log := xcontext.Logger(ctx)
+
+	res.out = map[string]any{}
+
+	pag := opts.paginate
+	maxPages := ptr.Deref(pag.MaxPages, defaultMaxPages)
+
+	call := opts.call
+	pos := paginationStart(pag)
+	if pag.Type == templates.PaginationPage || pag.Type == templates.PaginationOffset {
+		path, err := withQueryParam(call.Path, paginationParam(pag), strconv.Itoa(pos))
+		if err != nil {
+			res.status = response.New(http.StatusInternalServerError, err)
+			return
+		}
+		call.Path = path
+	}
+
+	all := []any{}
+	for num := 1; ; num++ {
+		var pg page
+		res.status = doWithRetry(ctx, opts, call, func(hr *http.Response) error {
+			dat, err := io.ReadAll(hr.Body)
+			if err != nil {
+				return err
+			}
+
+			pg.body, err = decodeBody(responseFormat(opts.format, hr.Header.Get("Content-Type")), dat)
+			if err != nil {
+				return err
+			}
+			pg.link = nextLink(hr.Header.Values("Link"))
+			return nil
+		})
+		if num == 1 {
+			recordRead(ctx, opts, call, res.status, pg.body)
+		}
+		if isFailure(res.status) {
+			return
+		}
+
+		items, err := pageItems(pag, pg.body)
+		if err != nil {
+			res.status = response.New(http.StatusInternalServerError, err)
+			return
+		}
+		all = append(all, items...)
+
+		next, err := nextPagePath(pag, call, pg, len(items), &pos)
+		if err != nil {
+			res.status = response.New(http.StatusInternalServerError, err)
+			return
+		}
+		if len(next) == 0 {
+			break
+		}
+
+		if num == maxPages {
+			log.Warn("maximum number of pages reached", slog.String("name", opts.id),
+				slog.String("path", opts.secrets.redact(opts.call.Path)), slog.Int("maxPages", maxPages))
+			break
+		}
+		call.Path = next
+	}
+
+	tmp, err := applyFilter(ctx, jsonHandlerOptions{
+		key: opts.id, out: res.out, slice: opts.slice, filter: opts.filter,
+	}, all)
+	if err != nil {
+		res.status = response.New(http.StatusInternalServerError, err)
+		return
+	}
+	accumulate(res.out, opts.id, tmp)
+
+	return
+}
+
+// pageItems extracts the items from the page body.
+func pageItems(pag *templates.Pagination, body any) ([]any, error) {
+	got := body
+	if q := ptr.Deref(pag.Items, ""); len(q) > 0 {
+		var err error
+		got, err = evalOnPage(q, body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to extract page items: %w", err)
+		}
+	}
+
+	if got == nil {
+		return nil, nil
+	}
+
+	return wrapAsSlice(got), nil
+}
+
+// nextPagePath returns the path of the next page, or an empty
+// string when there are no more pages.
+func nextPagePath(pag *templates.Pagination, call httpcall.RequestOptions, pg page, count int, pos *int) (string, error) {
+	switch pag.Type {
+	case templates.PaginationLink:
+		if len(pg.link) == 0 {
+			return "", nil
+		}
+		return relativeLink(call.Endpoint.ServerURL, pg.link)
+
+	case templates.PaginationCursor:
+		q := ptr.Deref(pag.Next, "")
+		if len(q) == 0 {
+			return "", fmt.Errorf("cursor pagination requires the next expression")
+		}
+
+		got, err := evalOnPage(q, pg.body)
+		if err != nil {
+			return "", fmt.Errorf("unable to evaluate next cursor: %w", err)
+		}
+
+		cursor := ""
+		switch v := got.(type) {
+		case nil:
+		case string:
+			cursor = v
+		default:
+			cursor = fmt.Sprintf("%v", v)
+		}
+		if len(cursor) == 0 {
+			return "", nil
+		}
+
+		param := ptr.Deref(pag.Param, "")
+		if len(param) == 0 {
+			return "", fmt.Errorf("cursor pagination requires the param name")
+		}
+		return withQueryParam(call.Path, param, cursor)
+
+	case templates.PaginationPage, templates.PaginationOffset:
+		if count == 0 {
+			return "", nil
+		}
+
+		if pag.Type == templates.PaginationPage {
+			*pos += 1
+		} else {
+			*pos += count
+		}
+		return withQueryParam(call.Path, paginationParam(pag), strconv.Itoa(*pos))
+
+	default:
+		return "", fmt.Errorf("unsupported pagination type %q", pag.Type)
+	}
+}
+
+func paginationParam(pag *templates.Pagination) string {
+	if s := ptr.Deref(pag.Param, ""); len(s) > 0 {
+		return s
+	}
+	if pag.Type == templates.PaginationOffset {
+		return "offset"
+	}
+	return "page"
+}
+
+func paginationStart(pag *templates.Pagination) int {
+	if pag.Start != nil {
+		return *pag.Start
+	}
+	if pag.Type == templates.PaginationPage {
+		return 1
+	}
+	return 0
+}
+
+// evalOnPage evaluates the JQ expression against the page body.
+func evalOnPage(q string, body any) (any, error) {
+	s, err := jqutil.Eval(context.TODO(), jqutil.EvalOptions{
+		Query: q, Data: body,
+		ModuleLoader: jqsupport.ModuleLoader(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(strings.TrimSpace(s)) == 0 {
+		return nil, nil
+	}
+
+	var res any
+	if err := json.Unmarshal([]byte(s), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// withQueryParam sets the query parameter on the request path.
+func withQueryParam(path, key, val string) (string, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set(key, val)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// nextLink returns the rel="next" URL of the Link response headers (RFC 8288).
+func nextLink(values []string) string {
+	for _, val := range values {
+		for _, el := range strings.Split(val, ",") {
+			parts := strings.Split(el, ";")
+			if len(parts) < 2 {
+				continue
+			}
+
+			for _, p := range parts[1:] {
+				k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+				if !ok || !strings.EqualFold(k, "rel") {
+					continue
+				}
+
+				for _, rel := range strings.Fields(strings.Trim(v, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+					}
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// relativeLink converts the next page link into a path relative to the endpoint server URL.
+func relativeLink(serverURL, link string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	if !u.IsAbs() {
+		return link, nil
+	}
+
+	base := strings.TrimSuffix(serverURL, "/")
+	if !strings.HasPrefix(link, base) {
+		return "", fmt.Errorf("next page link %q does not belong to endpoint %q", link, serverURL)
+	}
+
+	return strings.TrimPrefix(link, base), nil
+}
@@ -0,0 +1,166 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/endpoints"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+)
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{
+			values: []string{`<https://api.github.com/repos?page=2>; rel="next", <https://api.github.com/repos?page=5>; rel="last"`},
+			want:   "https://api.github.com/repos?page=2",
+		},
+		{
+			values: []string{`</items?page=1>; rel="prev"`, `</items?page=3>; rel=next`},
+			want:   "/items?page=3",
+		},
+		{
+			values: []string{`<https://api.github.com/repos?page=1>; rel="first"`},
+			want:   "",
+		},
+		{},
+	}
+
+	for i, tc := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if got := nextLink(tc.values); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDoPaginatedCall(t *testing.T) {
+	const total = 5
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/link":
+			n, _ := strconv.Atoi(q.Get("p"))
+			if n < total-1 {
+				w.Header().Set("Link", fmt.Sprintf(`<http://%s/link?p=%d>; rel="next"`, r.Host, n+1))
+			}
+			fmt.Fprintf(w, `[%d]`, n)
+
+		case "/cursor":
+			n, _ := strconv.Atoi(q.Get("continue"))
+			next := ""
+			if n < total-1 {
+				next = strconv.Itoa(n + 1)
+			}
+			fmt.Fprintf(w, `{"items": [%d], "metadata": {"continue": %q}}`, n, next)
+
+		case "/page":
+			n, _ := strconv.Atoi(q.Get("page"))
+			if n > total {
+				fmt.Fprint(w, `{"data": []}`)
+				return
+			}
+			fmt.Fprintf(w, `{"data": [%d]}`, n-1)
+
+		case "/offset":
+			n, _ := strconv.Atoi(q.Get("skip"))
+			items := ""
+			for i := n; i < min(n+2, total); i++ {
+				if i > n {
+					items += ","
+				}
+				items += strconv.Itoa(i)
+			}
+			fmt.Fprintf(w, `{"data": [%s]}`, items)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		paginate *templates.Pagination
+		filter   *string
+		expect   any
+	}{
+		{
+			name:     "link",
+			path:     "/link",
+			paginate: &templates.Pagination{Type: templates.PaginationLink},
+			expect:   []any{0, 1, 2, 3, 4},
+		},
+		{
+			name: "cursor",
+			path: "/cursor",
+			paginate: &templates.Pagination{
+				Type:  templates.PaginationCursor,
+				Items: ptr.To(".items"), Next: ptr.To(".metadata.continue"),
+				Param: ptr.To("continue"),
+			},
+			expect: []any{0, 1, 2, 3, 4},
+		},
+		{
+			name: "page",
+			path: "/page",
+			paginate: &templates.Pagination{
+				Type: templates.PaginationPage, Items: ptr.To(".data"),
+			},
+			filter: ptr.To(".test | length"),
+			expect: total,
+		},
+		{
+			name: "offset",
+			path: "/offset?limit=2",
+			paginate: &templates.Pagination{
+				Type: templates.PaginationOffset, Items: ptr.To(".data"),
+				Param: ptr.To("skip"),
+			},
+			expect: []any{0, 1, 2, 3, 4},
+		},
+		{
+			name: "max pages",
+			path: "/page",
+			paginate: &templates.Pagination{
+				Type: templates.PaginationPage, Items: ptr.To(".data"),
+				MaxPages: ptr.To(2),
+			},
+			expect: []any{0, 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := doCall(context.Background(), callOptions{
+				id: "test",
+				call: httpcall.RequestOptions{
+					RequestInfo: httpcall.RequestInfo{Path: tc.path},
+					Endpoint:    &endpoints.Endpoint{ServerURL: srv.URL},
+				},
+				filter:   tc.filter,
+				paginate: tc.paginate,
+			})
+			if res.failed() {
+				t.Fatalf("unexpected failure: %v", res.status)
+			}
+
+			if !deepEqual(res.out["test"], tc.expect) {
+				t.Errorf("expected %v, got %v", tc.expect, res.out["test"])
+			}
+		})
+	}
+}
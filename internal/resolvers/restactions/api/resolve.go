@@ -2,21 +2,36 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/env"
 	httpcall "github.com/krateoplatformops/plumbing/http/request"
 	"github.com/krateoplatformops/plumbing/http/response"
 	"github.com/krateoplatformops/plumbing/maps"
 	"github.com/krateoplatformops/plumbing/ptr"
 	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/fixtures"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/trace"
+	"github.com/krateoplatformops/snowplow/internal/signing"
 	"k8s.io/client-go/rest"
 )
 
 const (
 	//annotationKeyVerboseAPI = "krateo.io/verbose"
 	headerAcceptJSON = "Accept: application/json"
+
+	// EnvMaxConcurrency is the environment variable holding the maximum
+	// number of API calls resolved concurrently.
+	EnvMaxConcurrency = "API_MAX_CONCURRENCY"
+
+	defaultMaxConcurrency = 8
 )
 
 type ResolveOptions struct {
@@ -28,18 +43,30 @@ type ResolveOptions struct {
 	Page    int
 	Cursor  string
 	Extras  map[string]any
+	// MaxConcurrency is the maximum number of independent APIs
+	// resolved at the same time (API_MAX_CONCURRENCY if not set).
+	MaxConcurrency int
+	// RESTActionResolver resolves the APIs referencing other RESTActions.
+	RESTActionResolver RESTActionResolver
+	// Namespace is the RESTAction namespace, where the env
+	// Secrets and ConfigMaps are read.
+	Namespace string
 }
 
-func Resolve(ctx context.Context, opts ResolveOptions) map[string]any {
+// Resolve performs all the API calls and returns the resulting dict together
+// with the outcome of each API; an error is returned only when the APIs
+// cannot be resolved at all (i.e. invalid dependencies).
+func Resolve(ctx context.Context, opts ResolveOptions) (map[string]any, []Outcome, error) {
 	if len(opts.Items) == 0 {
-		return map[string]any{}
+		return map[string]any{}, nil, nil
 	}
 
-	if opts.RC == nil {
+	// replaying fixtures does not require the cluster
+	if opts.RC == nil && !fixtures.FromContext(ctx).Replaying() {
 		var err error
 		opts.RC, err = rest.InClusterConfig()
 		if err != nil {
-			return map[string]any{}
+			return map[string]any{}, nil, err
 		}
 	}
 
@@ -49,25 +76,21 @@ func Resolve(ctx context.Context, opts ResolveOptions) map[string]any {
 	user, err := xcontext.UserInfo(ctx)
 	if err != nil {
 		log.Error("unable to fetch user info from context", slog.Any("err", err))
-		return map[string]any{}
+		return map[string]any{}, nil, err
 	}
 
-	// Sort API by Depends
-	names, err := topologicalSort(opts.Items)
+	// Group API by dependency levels
+	levels, err := topologicalLevels(opts.Items)
 	if err != nil {
 		log.Error("unable to sorted api by deps", slog.Any("error", err))
-		return map[string]any{}
+		return map[string]any{}, nil, err
 	}
-	log.Debug("sorted api by deps", slog.Any("names", names))
+	log.Debug("sorted api by deps", slog.Any("levels", levels))
+	trace.FromContext(ctx).SetOrder(levels)
 
 	apiMap := make(map[string]*templates.API, len(opts.Items))
-	for _, id := range names {
-		for _, el := range opts.Items {
-			if el.Name == id {
-				apiMap[id] = el
-				break
-			}
-		}
+	for _, el := range opts.Items {
+		apiMap[el.Name] = el
 	}
 	log.Debug("created api map", slog.Int("total", len(apiMap)))
 
@@ -78,6 +101,8 @@ func Resolve(ctx context.Context, opts ResolveOptions) map[string]any {
 		rc:       opts.RC,
 	}
 
+	envs := &envSource{rc: opts.RC, namespace: opts.Namespace}
+
 	dict := map[string]any{}
 	if opts.Extras != nil {
 		dict = maps.DeepCopyJSON(opts.Extras)
@@ -98,88 +123,556 @@ func Resolve(ctx context.Context, opts ResolveOptions) map[string]any {
 
 	log.Info("base dict for api resolver", slog.Any("dict", dict))
 
-	for _, id := range names {
-		// Get the api with this identifier
-		apiCall, ok := apiMap[id]
-		if !ok {
-			log.Warn("api not found in apiMap", slog.Any("name", id))
-			continue
-		}
-		if apiCall.Headers == nil {
-			apiCall.Headers = []string{headerAcceptJSON}
+	limit := opts.MaxConcurrency
+	if limit <= 0 {
+		limit = env.Int(EnvMaxConcurrency, defaultMaxConcurrency)
+	}
+
+	outcomes := make([]Outcome, 0, len(opts.Items))
+	skipped := map[string]bool{}
+	for _, level := range levels {
+		// Each API of the level writes into its own dict,
+		// merged into the shared one once the whole level is done.
+		results := make([]stepResult, len(level))
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, max(limit, 1))
+		for i, id := range level {
+			// Get the api with this identifier
+			apiCall, ok := apiMap[id]
+			if !ok {
+				log.Warn("api not found in apiMap", slog.Any("name", id))
+				continue
+			}
+
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+
+				res := resolveStep(ctx, stepOptions{
+					api:            apiCall,
+					mapper:         &mapper,
+					dict:           dict,
+					skipped:        skipped,
+					verbose:        opts.Verbose,
+					maxConcurrency: limit,
+					resolver:       opts.RESTActionResolver,
+					env:            envs,
+				})
+				recoverStep(ctx, apiCall, dict, &res)
+				results[i] = res
+			}()
 		}
+		wg.Wait()
 
-		if accessToken, _ := xcontext.AccessToken(ctx); accessToken != "" {
-			if apiCall.EndpointRef == nil || ptr.Deref(apiCall.ExportJWT, false) {
-				apiCall.Headers = append(apiCall.Headers,
-					fmt.Sprintf("Authorization: Bearer %s", accessToken))
+		halt := false
+		for i, res := range results {
+			if _, ok := apiMap[level[i]]; ok {
+				outcomes = append(outcomes, res.outcome(level[i]))
 			}
+			if res.skipped {
+				skipped[level[i]] = true
+				continue
+			}
+			for k, v := range res.out {
+				dict[k] = v
+			}
+			halt = halt || res.halt
+		}
+		if halt {
+			return dict, outcomes, nil
 		}
+	}
 
-		// Resolve the endpoint
-		ep, err := mapper.resolveOne(ctx, apiCall.EndpointRef)
-		if err != nil {
-			log.Error("unable to resolve api endpoint reference",
-				slog.String("name", id), slog.Any("ref", apiCall.EndpointRef), slog.Any("error", err))
-			return dict
+	removeManagedFields(dict)
+	//delete(dict, "slice")
+
+	return dict, outcomes, nil
+}
+
+type stepOptions struct {
+	api            *templates.API
+	mapper         *endpointReferenceMapper
+	dict           map[string]any
+	skipped        map[string]bool
+	verbose        bool
+	maxConcurrency int
+	resolver       RESTActionResolver
+	env            *envSource
+}
+
+// stepResult holds the values produced by a single API;
+// halt is true when the resolution must not proceed further.
+type stepResult struct {
+	out     map[string]any
+	halt    bool
+	skipped bool
+	// reason is the reason why the step has been skipped
+	reason string
+	// failure is the first failure of the step (if any)
+	failure *response.Status
+}
+
+// fail records the failure of the step: the resolution halts
+// unless the step is allowed to continue on error.
+func (r *stepResult) fail(status *response.Status, continueOnError bool) {
+	if r.failure == nil {
+		r.failure = status
+	}
+	r.halt = r.halt || !continueOnError
+}
+
+// outcome returns the outcome of the step.
+func (r *stepResult) outcome(id string) Outcome {
+	switch {
+	case r.skipped:
+		return Outcome{API: id, State: OutcomeSkipped, Message: r.reason}
+	case r.failure != nil:
+		return Outcome{
+			API: id, State: OutcomeFailed,
+			Code: r.failure.Code, Message: r.failure.Message,
+			ContinueOnError: !r.halt,
 		}
-		if opts.Verbose {
-			ep.Debug = opts.Verbose
+	default:
+		return Outcome{API: id, State: OutcomeSuccess}
+	}
+}
+
+// resolveStep performs all the HTTP calls of a single API. The shared dict
+// is only read, results are collected into the step own output.
+func resolveStep(ctx context.Context, opts stepOptions) (res stepResult) {
+	log := xcontext.Logger(ctx)
+
+	id := opts.api.Name
+	apiCall := opts.api
+	res.out = map[string]any{}
+
+	reason, skip, err := skipReason(apiCall, opts.dict, opts.skipped)
+	if err != nil {
+		// an invalid condition is an authoring mistake, not a skip
+		status := response.New(http.StatusInternalServerError, err)
+		log.Error("unable to evaluate api condition", slog.String("name", id), slog.Any("err", err))
+		storeFailure(log, res.out, ptr.Deref(apiCall.ErrorKey, "error"), status)
+		res.fail(status, ptr.Deref(apiCall.ContinueOnError, false))
+		return
+	}
+	if skip {
+		res.skipped, res.reason = true, reason
+
+		level := slog.LevelDebug
+		if opts.verbose {
+			level = slog.LevelInfo
 		}
-		log.Debug("resolved endpoint for api call",
-			slog.String("name", id), slog.String("host", ep.ServerURL))
+		log.Log(ctx, level, "api skipped", slog.String("name", id), slog.String("reason", reason))
+		trace.FromContext(ctx).AddSkip(trace.Skip{
+			API: id, RESTAction: trace.Scope(ctx), Reason: reason,
+		})
+		return
+	}
 
-		tmp := createRequestOptions(log, apiCall, dict)
-		if len(tmp) == 0 {
-			log.Warn("empty request options for http call", slog.Any("name", id))
-			continue
+	if apiCall.Timeout != nil && apiCall.Timeout.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, apiCall.Timeout.Duration)
+		defer cancel()
+	}
+
+	if apiCall.RESTActionRef != nil {
+		return resolveRESTActionRef(ctx, opts)
+	}
+
+	if apiCall.Kubernetes != nil {
+		return resolveKubernetes(ctx, opts)
+	}
+
+	if apiCall.Headers == nil {
+		accept := headerAcceptJSON
+		if apiCall.ResponseFormat != nil {
+			if el, ok := acceptHeaders[*apiCall.ResponseFormat]; ok {
+				accept = el
+			}
+		}
+		apiCall.Headers = []string{accept}
+	}
+
+	if accessToken, _ := xcontext.AccessToken(ctx); accessToken != "" {
+		if apiCall.EndpointRef == nil || ptr.Deref(apiCall.ExportJWT, false) {
+			apiCall.Headers = append(apiCall.Headers,
+				fmt.Sprintf("Authorization: Bearer %s", accessToken))
+		}
+	}
+
+	// Resolve the endpoint
+	ep, err := opts.mapper.resolveOne(ctx, apiCall.EndpointRef)
+	if err != nil {
+		log.Error("unable to resolve api endpoint reference",
+			slog.String("name", id), slog.Any("ref", apiCall.EndpointRef), slog.Any("error", err))
+		res.fail(statusFromError(fmt.Errorf("unable to resolve endpoint reference: %w", err)), false)
+		return
+	}
+	if opts.verbose {
+		ep.Debug = opts.verbose
+	}
+	log.Debug("resolved endpoint for api call",
+		slog.String("name", id), slog.String("host", ep.ServerURL))
+
+	ext, err := opts.mapper.extensions(ctx, apiCall.EndpointRef, ep)
+	if err != nil {
+		log.Error("unable to resolve api endpoint authentication",
+			slog.String("name", id), slog.Any("ref", apiCall.EndpointRef), slog.Any("error", err))
+		res.fail(statusFromError(fmt.Errorf("unable to resolve endpoint authentication: %w", err)), false)
+		return
+	}
+
+	var env map[string]string
+	if !fixtures.FromContext(ctx).Replaying() {
+		// replayed requests keep the $(NAME) references, as recorded
+		env, err = opts.env.resolve(ctx, apiCall.Env)
+	}
+	if err != nil {
+		log.Error("unable to resolve api env",
+			slog.String("name", id), slog.Any("error", err))
+		res.fail(statusFromError(err), false)
+		return
+	}
+	secrets := envSecrets(env)
+	if len(secrets) > 0 && ep.Debug {
+		// the request dump would expose the env values
+		log.Debug("request dump disabled for api with env", slog.String("name", id))
+		ep.Debug = false
+	}
+
+	tmp := createRequestOptions(log, apiCall, opts.dict, env)
+	if len(tmp) == 0 {
+		log.Warn("empty request options for http call", slog.Any("name", id))
+		newMerger(id, apiCall.Merge).init(res.out)
+		return
+	}
+
+	limit := opts.maxConcurrency
+	if apiCall.MaxConcurrency != nil {
+		limit = ptr.Deref(apiCall.MaxConcurrency, limit)
+	}
+
+	for i := range tmp {
+		tmp[i].Endpoint = &ep
+	}
+
+	res = runCalls(ctx, runOptions{
+		id: id, calls: tmp, filter: apiCall.Filter, retry: apiCall.Retry,
+		format: apiCall.ResponseFormat, paginate: apiCall.Paginate,
+		slice: opts.dict["slice"], maxConcurrency: limit, merge: apiCall.Merge,
+		secrets: secrets, auth: ext.oauth2, signer: ext.signer,
+		internal: apiCall.EndpointRef == nil,
+	})
+	if res.halt {
+		return
+	}
+
+	log.Info("api successfully resolved",
+		slog.String("name", id),
+		slog.String("host", ep.ServerURL), slog.Int("calls", len(tmp)),
+		slog.Any("depth", mapDepth(res.out)),
+	)
+
+	return
+}
+
+// skipReason reports whether the API must be skipped: when it iterates over
+// a skipped dependency or when its `when` condition is not satisfied.
+// A condition that cannot be evaluated is an error.
+func skipReason(in *templates.API, dict map[string]any, skipped map[string]bool) (string, bool, error) {
+	if dep := iteratedDependency(in); len(dep) > 0 && skipped[dep] {
+		return fmt.Sprintf("iterated dependency %q skipped", dep), true, nil
+	}
+
+	if in.When == nil {
+		return "", false, nil
+	}
+
+	ok, err := evalCondition(*in.When, dict)
+	if err != nil {
+		return "", false, fmt.Errorf("unable to evaluate condition %q: %w", *in.When, err)
+	}
+	if !ok {
+		return fmt.Sprintf("condition %q not satisfied", *in.When), true, nil
+	}
+
+	return "", false, nil
+}
+
+type runOptions struct {
+	id             string
+	calls          []httpcall.RequestOptions
+	filter         *string
+	slice          any
+	retry          *templates.RetryPolicy
+	format         *templates.ResponseFormat
+	paginate       *templates.Pagination
+	merge          *templates.MergePolicy
+	maxConcurrency int
+	secrets        secretValues
+	auth           *oauth2Config
+	signer         signing.Signer
+	internal       bool
+}
+
+// runCalls performs the HTTP calls of an API concurrently (at most maxConcurrency
+// at a time) and merges the results following the calls order.
+func runCalls(ctx context.Context, opts runOptions) (res stepResult) {
+	log := xcontext.Logger(ctx)
+
+	res.out = map[string]any{}
+
+	m := newMerger(opts.id, opts.merge)
+	m.init(res.out)
+
+	// Each call writes into its own slot.
+	results := make([]callResult, len(opts.calls))
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(opts.maxConcurrency, 1))
+	for i, call := range opts.calls {
+		sem <- struct{}{}
+		if stop.Load() {
+			<-sem
+			break
 		}
 
-		for _, call := range tmp {
-			call.Endpoint = &ep
-			call.ResponseHandler = jsonHandler(ctx, jsonHandlerOptions{
-				key: id, out: dict, filter: apiCall.Filter,
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			results[i] = doCall(ctx, callOptions{
+				id: opts.id, call: call, filter: opts.filter,
+				slice: opts.slice, retry: opts.retry, format: opts.format,
+				paginate: opts.paginate, secrets: opts.secrets, auth: opts.auth,
+				signer: opts.signer, internal: opts.internal,
 			})
+			if results[i].failed() && !call.ContinueOnError {
+				stop.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
 
-			log.Debug("calling api", slog.String("name", id),
-				slog.String("host", call.Endpoint.ServerURL), slog.String("path", call.Path),
-				slog.Any("out", dict),
-			)
-
-			res := httpcall.Do(ctx, call)
-			if res.Status == response.StatusFailure {
-				log.Error("api call response failure", slog.String("name", id),
-					slog.String("host", call.Endpoint.ServerURL), slog.String("path", call.Path),
-					slog.String("error", res.Message))
-
-				tmp, err := response.AsMap(res)
-				if err != nil {
-					log.Warn("unable to encode status as dict", slog.Any("err", err))
-				}
-
-				if len(tmp) > 0 {
-					dict[call.ErrorKey] = tmp
-				} else {
-					dict[call.ErrorKey] = res.Message
-				}
-
-				if !call.ContinueOnError {
-					return dict
-				}
+	// Merge the results following the calls order.
+	for i, rt := range results {
+		if rt.status == nil {
+			break
+		}
+
+		call := opts.calls[i]
+		if rt.failed() {
+			storeFailure(log, res.out, call.ErrorKey, rt.status)
+
+			res.fail(rt.status, call.ContinueOnError)
+			if res.halt {
+				return
 			}
+			continue
+		}
 
-			log.Info("api successfully resolved",
-				slog.String("name", id),
-				slog.String("host", call.Endpoint.ServerURL), slog.String("path", call.Path),
-				slog.Any("depth", mapDepth(dict)),
-			)
+		val, ok := rt.out[opts.id]
+		if !ok {
+			continue
+		}
+		if err := m.add(res.out, val); err != nil {
+			status := response.New(http.StatusInternalServerError,
+				fmt.Errorf("unable to merge api result: %w", err))
+			storeFailure(log, res.out, call.ErrorKey, status)
+
+			res.fail(status, call.ContinueOnError)
+			if res.halt {
+				return
+			}
 		}
 	}
 
-	removeManagedFields(dict)
-	//delete(dict, "slice")
+	return
+}
+
+// traceCall records the HTTP call attempt, if tracing is enabled.
+func traceCall(ctx context.Context, id string, call httpcall.RequestOptions, attempt int, status *response.Status, start time.Time, received int64) {
+	rec := trace.FromContext(ctx)
+	if rec == nil {
+		return
+	}
+
+	el := trace.Call{
+		API: id, RESTAction: trace.Scope(ctx), Kind: "http",
+		Verb: ptr.Deref(call.Verb, http.MethodGet), Path: call.Path,
+		Headers: trace.RedactHeaders(call.Headers), Attempt: attempt,
+		Status: status.Code, Duration: trace.Duration(start), Bytes: received,
+		Key: id,
+	}
+	if isFailure(status) {
+		el.Key, el.Error = call.ErrorKey, status.Message
+	}
+
+	rec.AddCall(el)
+}
+
+// storeFailure stores the failure status under the error key.
+func storeFailure(log *slog.Logger, out map[string]any, key string, status *response.Status) {
+	dat, err := response.AsMap(status)
+	if err != nil {
+		log.Warn("unable to encode status as dict", slog.Any("err", err))
+	}
+
+	if len(dat) > 0 {
+		out[key] = dat
+	} else {
+		out[key] = status.Message
+	}
+}
+
+type callOptions struct {
+	id       string
+	call     httpcall.RequestOptions
+	filter   *string
+	slice    any
+	retry    *templates.RetryPolicy
+	format   *templates.ResponseFormat
+	paginate *templates.Pagination
+	// secrets are the values hidden from logs and traces.
+	secrets secretValues
+	// auth, if set, is used to get the endpoint access token.
+	auth *oauth2Config
+	// signer, if set, signs each request.
+	signer signing.Signer
+	// internal is true when the call targets the internal endpoint
+	// (the Kubernetes API server).
+	internal bool
+}
+
+// callResult holds the outcome of a single HTTP call.
+type callResult struct {
+	out    map[string]any
+	status *response.Status
+}
+
+func (r *callResult) failed() bool {
+	return isFailure(r.status)
+}
+
+// timeoutStatus converts the status of a call interrupted
+// by a context deadline into a gateway timeout failure.
+func timeoutStatus(status *response.Status) *response.Status {
+	res := response.New(http.StatusGatewayTimeout,
+		fmt.Errorf("deadline exceeded: %s", status.Message))
+	res.Status = response.StatusFailure
+	res.Reason = response.StatusReasonTimeout
+	return res
+}
+
+// isFailure reports whether the status describes a failed call; some error
+// codes (e.g. 502, 504) are not marked as failures by response.New.
+func isFailure(status *response.Status) bool {
+	if status == nil {
+		return false
+	}
+	return status.Status == response.StatusFailure || status.Code >= http.StatusBadRequest
+}
+
+func doCall(ctx context.Context, opts callOptions) (res callResult) {
+	if opts.paginate != nil {
+		return doPaginatedCall(ctx, opts)
+	}
+
+	var body any
+	res.out = map[string]any{}
+	res.status = doWithRetry(ctx, opts, opts.call, func(hr *http.Response) error {
+		// discard anything left by a previous attempt
+		clear(res.out)
+
+		return jsonHandler(ctx, jsonHandlerOptions{
+			key: opts.id, out: res.out, slice: opts.slice, filter: opts.filter,
+			format:  responseFormat(opts.format, hr.Header.Get("Content-Type")),
+			decoded: func(v any) { body = v },
+		})(hr.Body)
+	})
+	recordRead(ctx, opts, opts.call, res.status, body)
+
+	return
+}
+
+// doWithRetry performs the HTTP call retrying it according to the API retry policy.
+func doWithRetry(ctx context.Context, opts callOptions, call httpcall.RequestOptions, handler func(*http.Response) error) (status *response.Status) {
+	log := xcontext.Logger(ctx)
+
+	attempts := maxAttempts(opts.retry)
+	shown := opts.secrets.redactCall(call)
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		log.Debug("calling api", slog.String("name", opts.id),
+			slog.String("host", call.Endpoint.ServerURL), slog.String("path", shown.Path),
+			slog.Int("attempt", attempt), slog.Int("maxAttempts", attempts),
+		)
+
+		start := time.Now()
+		call, status = withAccessToken(ctx, opts.auth, call)
+		if status != nil {
+			log.Error("unable to get endpoint access token", slog.String("name", opts.id),
+				slog.String("host", call.Endpoint.ServerURL), slog.String("error", status.Message))
+			return
+		}
+
+		var received int64
+		var transportErr error
+		status = httpDo(ctx, httpOptions{
+			call: call, handler: handler, noRetry: opts.retry != nil,
+			received: &received, transport: &transportErr,
+			signer: opts.signer, secrets: opts.secrets,
+		})
+		traceCall(ctx, opts.id, shown, attempt, status, start, received)
+		if !isFailure(status) {
+			return
+		}
+
+		if opts.auth != nil && status.Code == http.StatusUnauthorized {
+			// the token has been revoked or rejected: the next attempt gets a new one
+			subject, _ := xcontext.AccessToken(ctx)
+			tokens.invalidate(opts.auth, subject)
+		}
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			status = timeoutStatus(status)
+		}
+		status.Message = opts.secrets.redact(status.Message)
+
+		log.Error("api call response failure", slog.String("name", opts.id),
+			slog.String("host", call.Endpoint.ServerURL), slog.String("path", shown.Path),
+			slog.Int("attempt", attempt), slog.Int("maxAttempts", attempts),
+			slog.Int("code", status.Code), slog.String("error", status.Message))
+
+		if attempt == attempts || !shouldRetry(opts.retry, status, transportErr) {
+			return
+		}
+
+		wait := retryBackoff(opts.retry, attempt)
+		log.Warn("retrying api call", slog.String("name", opts.id),
+			slog.String("path", shown.Path), slog.Int("attempt", attempt+1),
+			slog.String("backoff", wait.String()))
+
+		if err := sleepWithContext(ctx, wait); err != nil {
+			log.Error("api call retry interrupted", slog.String("name", opts.id),
+				slog.String("path", shown.Path), slog.Any("err", err))
+			if errors.Is(err, context.DeadlineExceeded) {
+				status = timeoutStatus(status)
+			}
+			return
+		}
+	}
 
-	return dict
+	return
 }
 
 func removeManagedFields(data any) {
@@ -0,0 +1,424 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/krateoplatformops/plumbing/endpoints"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"github.com/krateoplatformops/snowplow/internal/dynamic"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/fixtures"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/trace"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRunCalls(t *testing.T) {
+	var inflight, peak atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cur := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			old := peak.Load()
+			if cur <= old || peak.CompareAndSwap(old, cur) {
+				break
+			}
+		}
+
+		idx, _ := strconv.Atoi(r.URL.Query().Get("idx"))
+		if idx == 7 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not here"))
+			return
+		}
+
+		// later calls answer first
+		time.Sleep(time.Duration(10-idx) * 5 * time.Millisecond)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"idx": %d}`, idx)
+	}))
+	defer srv.Close()
+
+	ep := &endpoints.Endpoint{ServerURL: srv.URL}
+
+	calls := make([]httpcall.RequestOptions, 10)
+	for i := range calls {
+		calls[i] = httpcall.RequestOptions{
+			RequestInfo: httpcall.RequestInfo{
+				Path: fmt.Sprintf("/items?idx=%d", i),
+				Verb: ptr.To(http.MethodGet),
+			},
+			Endpoint:        ep,
+			ErrorKey:        "failed",
+			ContinueOnError: true,
+		}
+	}
+
+	res := runCalls(context.Background(), runOptions{
+		id: "items", calls: calls, filter: ptr.To(".items.idx"), maxConcurrency: 4,
+	})
+	if res.halt {
+		t.Fatalf("unexpected halt")
+	}
+
+	want := []any{0.0, 1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 8.0, 9.0}
+	if !deepEqual(res.out["items"], want) {
+		t.Errorf("expected %v, got %v", want, res.out["items"])
+	}
+
+	if _, ok := res.out["failed"]; !ok {
+		t.Errorf("expected error under errorKey, got %v", res.out)
+	}
+
+	if got := peak.Load(); got < 2 || got > 4 {
+		t.Errorf("expected between 2 and 4 concurrent calls, got %d", got)
+	}
+}
+
+func TestRunCallsHaltOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("idx") == "1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not here"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	ep := &endpoints.Endpoint{ServerURL: srv.URL}
+
+	calls := make([]httpcall.RequestOptions, 3)
+	for i := range calls {
+		calls[i] = httpcall.RequestOptions{
+			RequestInfo: httpcall.RequestInfo{
+				Path: fmt.Sprintf("/items?idx=%d", i),
+			},
+			Endpoint: ep,
+			ErrorKey: "error",
+		}
+	}
+
+	res := runCalls(context.Background(), runOptions{
+		id: "items", calls: calls, maxConcurrency: 1,
+	})
+	if !res.halt {
+		t.Fatalf("expected halt")
+	}
+
+	if _, ok := res.out["error"]; !ok {
+		t.Errorf("expected error under errorKey, got %v", res.out)
+	}
+
+	if !deepEqual(res.out["items"], map[string]any{"ok": true}) {
+		t.Errorf("expected only the first result, got %v", res.out["items"])
+	}
+
+	got := res.outcome("items")
+	if got.State != OutcomeFailed || got.Code != http.StatusNotFound || got.ContinueOnError {
+		t.Errorf("unexpected outcome: %+v", got)
+	}
+}
+
+func TestRunCallsTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("idx") == "1" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	ep := &endpoints.Endpoint{ServerURL: srv.URL}
+
+	calls := make([]httpcall.RequestOptions, 2)
+	for i := range calls {
+		calls[i] = httpcall.RequestOptions{
+			RequestInfo: httpcall.RequestInfo{
+				Path: fmt.Sprintf("/items?idx=%d", i),
+			},
+			Endpoint:        ep,
+			ErrorKey:        "timedOut",
+			ContinueOnError: true,
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	res := runCalls(ctx, runOptions{
+		id: "items", calls: calls, maxConcurrency: 2,
+	})
+	if res.halt {
+		t.Fatalf("unexpected halt")
+	}
+
+	if !deepEqual(res.out["items"], map[string]any{"ok": true}) {
+		t.Errorf("expected the first result, got %v", res.out["items"])
+	}
+
+	status, ok := res.out["timedOut"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected status under errorKey, got %v", res.out)
+	}
+	if code := status["code"]; code != float64(http.StatusGatewayTimeout) {
+		t.Errorf("expected code 504, got %v", code)
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	dict := map[string]any{
+		"extras": map[string]any{"enabled": true},
+		"slice":  map[string]any{"page": 2},
+		"users":  []any{},
+	}
+
+	tests := []struct {
+		name    string
+		api     *templates.API
+		skipped map[string]bool
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "no condition",
+			api:  &templates.API{Name: "a"},
+			want: false,
+		},
+		{
+			name: "condition satisfied",
+			api:  &templates.API{Name: "a", When: ptr.To(".extras.enabled")},
+			want: false,
+		},
+		{
+			name: "wrapped condition satisfied",
+			api:  &templates.API{Name: "a", When: ptr.To("${ .slice.page > 1 }")},
+			want: false,
+		},
+		{
+			name: "condition not satisfied",
+			api:  &templates.API{Name: "a", When: ptr.To(".users | length > 0")},
+			want: true,
+		},
+		{
+			name: "condition yields null",
+			api:  &templates.API{Name: "a", When: ptr.To(".missing")},
+			want: true,
+		},
+		{
+			name:    "invalid condition",
+			api:     &templates.API{Name: "a", When: ptr.To(".users[")},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name: "iterated dependency skipped",
+			api: &templates.API{Name: "a", DependsOn: &templates.Dependency{
+				Name: "b", Iterator: ptr.To(".b"),
+			}},
+			skipped: map[string]bool{"b": true},
+			want:    true,
+		},
+		{
+			name: "not iterated dependency skipped",
+			api: &templates.API{Name: "a", DependsOn: &templates.Dependency{
+				Name: "b",
+			}},
+			skipped: map[string]bool{"b": true},
+			want:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, got, err := skipReason(tc.api, dict, tc.skipped)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected skip %v, got %v (%s)", tc.want, got, reason)
+			}
+		})
+	}
+}
+
+func TestResolveStepInvalidCondition(t *testing.T) {
+	for _, continueOnError := range []bool{false, true} {
+		t.Run(fmt.Sprintf("continueOnError=%v", continueOnError), func(t *testing.T) {
+			res := resolveStep(context.Background(), stepOptions{
+				api: &templates.API{
+					Name: "a", Path: "/a", When: ptr.To(".users["),
+					ContinueOnError: ptr.To(continueOnError),
+				},
+				dict: map[string]any{},
+			})
+
+			got := res.outcome("a")
+			if got.State != OutcomeFailed || got.Code != http.StatusInternalServerError {
+				t.Fatalf("expected a failed outcome, got %+v", got)
+			}
+			if res.halt == continueOnError {
+				t.Errorf("expected halt %v, got %v", !continueOnError, res.halt)
+			}
+			if _, ok := res.out["error"]; !ok {
+				t.Errorf("expected the failure under the error key, got %v", res.out)
+			}
+		})
+	}
+}
+
+func TestDoCallTrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not here"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	rec := trace.New()
+	ctx := trace.WithScope(trace.WithRecorder(context.Background(), rec), "demo/test")
+
+	for _, path := range []string{"/ok", "/missing"} {
+		doCall(ctx, callOptions{
+			id: "test",
+			call: httpcall.RequestOptions{
+				RequestInfo: httpcall.RequestInfo{
+					Path: path, Headers: []string{"Authorization: Bearer secret"},
+				},
+				Endpoint: &endpoints.Endpoint{ServerURL: srv.URL},
+				ErrorKey: "failure",
+			},
+		})
+	}
+
+	got := rec.Trace().Calls
+	if len(got) != 2 {
+		t.Fatalf("expected 2 calls, got %v", got)
+	}
+
+	ok := got[0]
+	if ok.API != "test" || ok.RESTAction != "demo/test" || ok.Kind != "http" || ok.Verb != http.MethodGet ||
+		ok.Path != "/ok" || ok.Status != http.StatusOK || ok.Bytes != 12 || ok.Key != "test" || ok.Attempt != 1 {
+		t.Errorf("unexpected call: %+v", ok)
+	}
+	if len(ok.Headers) != 1 || ok.Headers[0] != "Authorization: <redacted>" {
+		t.Errorf("expected redacted headers, got: %v", ok.Headers)
+	}
+
+	ko := got[1]
+	if ko.Status != http.StatusNotFound || ko.Key != "failure" || ko.Error != "not here" {
+		t.Errorf("unexpected call: %+v", ko)
+	}
+}
+
+func TestDoCallRecordsReads(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/demo/configmaps/nginx":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"kind":"ConfigMap","metadata":{"name":"nginx","resourceVersion":"7"}}`))
+		case "/api/v1/namespaces/demo/pods":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"kind":"PodList","metadata":{"resourceVersion":"9"},"items":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	reads := dynamic.NewReads()
+	ctx := dynamic.WithReads(context.Background(), reads)
+
+	call := func(path string, internal bool) {
+		doCall(ctx, callOptions{
+			id: "test", internal: internal,
+			filter: ptr.To(".test | .kind"),
+			call: httpcall.RequestOptions{
+				RequestInfo: httpcall.RequestInfo{Path: path},
+				Endpoint:    &endpoints.Endpoint{ServerURL: srv.URL},
+				ErrorKey:    "error",
+			},
+		})
+	}
+
+	call("/api/v1/namespaces/demo/configmaps/nginx", true)
+	call("/api/v1/namespaces/demo/pods?labelSelector=app%3Dweb", true)
+	call("/api/v1/namespaces/demo/secrets/missing", true)
+	// not the internal endpoint
+	call("/api/v1/namespaces/demo/configmaps/other", false)
+
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	want := []dynamic.Options{
+		{GVR: configMaps, Namespace: "demo", FieldSelector: "metadata.name=nginx", ResourceVersion: "7"},
+		{GVR: pods, Namespace: "demo", LabelSelector: "app=web", ResourceVersion: "9"},
+		{GVR: secrets, Namespace: "demo", FieldSelector: "metadata.name=missing"},
+	}
+	if got := reads.WatchOptions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestDoCallFixtures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"key": %q}`, r.URL.Query().Get("key"))
+	}))
+
+	call := httpcall.RequestOptions{
+		RequestInfo: httpcall.RequestInfo{Path: "/items?key=s3cr3t"},
+		Endpoint:    &endpoints.Endpoint{ServerURL: srv.URL},
+	}
+	secrets := envSecrets(map[string]string{"KEY": "s3cr3t"})
+
+	rec := fixtures.NewRecorder()
+	res := doCall(fixtures.WithSession(context.Background(), rec), callOptions{
+		id: "test", call: call, secrets: secrets,
+	})
+	srv.Close()
+
+	if want := map[string]any{"key": "s3cr3t"}; !deepEqual(res.out["test"], want) {
+		t.Fatalf("expected %v, got %v", want, res.out["test"])
+	}
+
+	fix := rec.Fixture()
+	if len(fix.Exchanges) != 1 || fix.Exchanges[0].URL != srv.URL+"/items?key=$(KEY)" {
+		t.Fatalf("unexpected exchanges: %+v", fix.Exchanges)
+	}
+
+	// in replay mode the env values are not resolved
+	call.Path = "/items?key=$(KEY)"
+	res = doCall(fixtures.WithSession(context.Background(), fixtures.NewPlayer(fix)), callOptions{
+		id: "test", call: call,
+	})
+	if want := map[string]any{"key": "$(KEY)"}; !deepEqual(res.out["test"], want) {
+		t.Fatalf("expected %v, got %v (%v)", want, res.out["test"], res.status)
+	}
+}
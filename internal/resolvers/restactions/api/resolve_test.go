@@ -123,7 +123,7 @@ func TestResolveAPI(t *testing.T) {
 				t.Fail()
 			}
 
-			res := Resolve(ctx, ResolveOptions{
+			res, _, _ := Resolve(ctx, ResolveOptions{
 				RC:      cfg.Client().RESTConfig(),
 				AuthnNS: cfg.Namespace(),
 				Items:   cr.Spec.API,
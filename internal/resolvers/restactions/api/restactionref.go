@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	xcontext "github.com/krateoplatformops/plumbing/context"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/plumbing/jqutil"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/trace"
+	jqsupport "github.com/krateoplatformops/snowplow/internal/support/jq"
+)
+
+// RESTActionResolver resolves the referenced RESTAction with the given extras
+// and returns its (filtered) status.
+type RESTActionResolver func(ctx context.Context, ref templates.Reference, extras map[string]any) (any, *response.Status)
+
+// resolveRESTActionRef resolves an API step referencing another RESTAction.
+func resolveRESTActionRef(ctx context.Context, opts stepOptions) (res stepResult) {
+	log := xcontext.Logger(ctx)
+
+	id := opts.api.Name
+	ref := opts.api.RESTActionRef
+	errorKey := ptr.Deref(opts.api.ErrorKey, "error")
+
+	res.out = map[string]any{}
+
+	fail := func(status *response.Status) stepResult {
+		log.Error("unable to resolve referenced restaction", slog.String("name", id),
+			slog.String("ref", fmt.Sprintf("%s/%s", ref.Namespace, ref.Name)),
+			slog.Int("code", status.Code), slog.String("error", status.Message))
+
+		storeFailure(log, res.out, errorKey, status)
+		res.fail(status, ptr.Deref(opts.api.ContinueOnError, false))
+		return res
+	}
+
+	if opts.resolver == nil {
+		return fail(response.New(http.StatusNotImplemented,
+			fmt.Errorf("restActionRef is not supported in this context")))
+	}
+
+	extras, err := evalData(ref.Extras, opts.dict)
+	if err != nil {
+		return fail(response.New(http.StatusBadRequest, err))
+	}
+
+	start := time.Now()
+	got, status := opts.resolver(ctx, ref.Reference, extras)
+	if rec := trace.FromContext(ctx); rec != nil {
+		el := trace.Call{
+			API: id, RESTAction: trace.Scope(ctx), Kind: "restActionRef",
+			Path:   fmt.Sprintf("%s/%s", ref.Namespace, ref.Name),
+			Status: http.StatusOK, Duration: trace.Duration(start), Key: id,
+		}
+		if status != nil {
+			el.Status, el.Error, el.Key = status.Code, status.Message, errorKey
+		}
+		rec.AddCall(el)
+	}
+	if status != nil {
+		status.Status = response.StatusFailure
+		return fail(status)
+	}
+
+	tmp, err := applyFilter(ctx, jsonHandlerOptions{
+		key: id, out: res.out, slice: opts.dict["slice"], filter: opts.api.Filter,
+	}, got)
+	if err != nil {
+		return fail(response.New(http.StatusInternalServerError, err))
+	}
+	if err := newMerger(id, opts.api.Merge).add(res.out, tmp); err != nil {
+		return fail(response.New(http.StatusInternalServerError,
+			fmt.Errorf("unable to merge api result: %w", err)))
+	}
+
+	log.Info("api successfully resolved",
+		slog.String("name", id),
+		slog.String("ref", fmt.Sprintf("%s/%s", ref.Namespace, ref.Name)),
+	)
+
+	return
+}
+
+// evalData evaluates the data values against the data source: JQ expressions
+// results are decoded as JSON values, unless AsString is true.
+func evalData(items []templates.Data, ds any) (map[string]any, error) {
+	res := make(map[string]any, len(items))
+	for _, el := range items {
+		if len(el.Name) == 0 {
+			continue
+		}
+
+		q, ok := jqutil.MaybeQuery(el.Value)
+		if !ok {
+			res[el.Name] = el.Value
+			continue
+		}
+
+		s, err := jqutil.Eval(context.TODO(), jqutil.EvalOptions{
+			Query: q, Data: ds,
+			Unquote:      ptr.Deref(el.AsString, false),
+			ModuleLoader: jqsupport.ModuleLoader(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to evaluate %q: %w", el.Name, err)
+		}
+
+		if ptr.Deref(el.AsString, false) {
+			res[el.Name] = s
+			continue
+		}
+
+		var val any
+		if len(strings.TrimSpace(s)) > 0 {
+			if err := json.Unmarshal([]byte(s), &val); err != nil {
+				return nil, fmt.Errorf("unable to decode %q: %w", el.Name, err)
+			}
+		}
+		res[el.Name] = val
+	}
+
+	return res, nil
+}
@@ -0,0 +1,77 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+)
+
+func TestResolveRESTActionRef(t *testing.T) {
+	dict := map[string]any{
+		"projects": []any{map[string]any{"name": "alpha"}, map[string]any{"name": "beta"}},
+	}
+
+	var gotRef templates.Reference
+	var gotExtras map[string]any
+	resolver := func(ctx context.Context, ref templates.Reference, extras map[string]any) (any, *response.Status) {
+		gotRef, gotExtras = ref, extras
+		if ref.Name == "missing" {
+			return nil, response.New(http.StatusNotFound, fmt.Errorf("restaction %q not found", ref.Name))
+		}
+		return map[string]any{"count": len(extras["names"].([]any))}, nil
+	}
+
+	ref := &templates.RESTActionReference{
+		Reference: templates.Reference{Name: "counter", Namespace: "demo"},
+		Extras: []templates.Data{
+			{Name: "names", Value: "${ .projects | map(.name) }"},
+			{Name: "total", Value: "${ .projects | length }", AsString: ptr.To(true)},
+			{Name: "kind", Value: "project"},
+		},
+	}
+
+	res := resolveRESTActionRef(context.Background(), stepOptions{
+		api: &templates.API{
+			Name: "stats", RESTActionRef: ref, Filter: ptr.To(".stats.count"),
+		},
+		dict: dict, resolver: resolver,
+	})
+	if res.halt {
+		t.Fatalf("unexpected halt: %v", res.out)
+	}
+	if gotRef != ref.Reference {
+		t.Errorf("unexpected reference: %v", gotRef)
+	}
+
+	expectExtras := map[string]any{"names": []any{"alpha", "beta"}, "total": "2", "kind": "project"}
+	if !deepEqual(gotExtras, expectExtras) {
+		t.Errorf("expected extras %v, got %v", expectExtras, gotExtras)
+	}
+	if !deepEqual(res.out["stats"], 2) {
+		t.Errorf("expected 2, got %v", res.out["stats"])
+	}
+
+	ref.Name = "missing"
+	res = resolveRESTActionRef(context.Background(), stepOptions{
+		api: &templates.API{
+			Name: "stats", RESTActionRef: ref, ErrorKey: ptr.To("statsError"),
+		},
+		dict: dict, resolver: resolver,
+	})
+	if !res.halt {
+		t.Fatal("expected halt")
+	}
+
+	got, ok := res.out["statsError"].(map[string]any)
+	if !ok || got["code"] != float64(http.StatusNotFound) {
+		t.Errorf("expected not found failure, got %v", res.out)
+	}
+}
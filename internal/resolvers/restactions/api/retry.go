@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/krateoplatformops/plumbing/http/response"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+)
+
+const (
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+)
+
+var (
+	defaultRetryStatusCodes = []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+
+	defaultRetryErrors = []templates.RetryErrorClass{
+		templates.RetryOnTimeout,
+		templates.RetryOnConnection,
+	}
+
+	errorClassPatterns = map[templates.RetryErrorClass][]string{
+		templates.RetryOnTimeout: {
+			"timeout", "deadline exceeded", "i/o timeout",
+		},
+		templates.RetryOnConnection: {
+			"connection reset", "connection refused", "broken pipe", "eof",
+			"server closed idle connection",
+		},
+		templates.RetryOnDNS: {
+			"no such host", "server misbehaving",
+		},
+	}
+)
+
+// maxAttempts returns the total number of attempts allowed by the policy.
+func maxAttempts(policy *templates.RetryPolicy) int {
+	if policy == nil || policy.MaxAttempts < 1 {
+		return 1
+	}
+	return policy.MaxAttempts
+}
+
+// shouldRetry reports whether the failed call matches the policy: its
+// status code or, when no response has been received, its transport error.
+func shouldRetry(policy *templates.RetryPolicy, status *response.Status, transport error) bool {
+	if policy == nil || !isFailure(status) {
+		return false
+	}
+
+	codes, classes := policy.StatusCodes, policy.Errors
+	if len(codes) == 0 && len(classes) == 0 {
+		codes, classes = defaultRetryStatusCodes, defaultRetryErrors
+	}
+
+	if slices.Contains(codes, status.Code) {
+		return true
+	}
+
+	cls, ok := errorClassOf(transport)
+	return ok && slices.Contains(classes, cls)
+}
+
+// errorClassOf classifies the transport error of a request that got no
+// response; response bodies are never classified.
+func errorClassOf(err error) (templates.RetryErrorClass, bool) {
+	if err == nil {
+		return "", false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return templates.RetryOnDNS, true
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return templates.RetryOnTimeout, true
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return templates.RetryOnConnection, true
+	}
+
+	// untyped transport errors (i.e. server closed idle connection)
+	msg := strings.ToLower(err.Error())
+	for _, cls := range []templates.RetryErrorClass{
+		templates.RetryOnDNS, templates.RetryOnTimeout, templates.RetryOnConnection,
+	} {
+		for _, pat := range errorClassPatterns[cls] {
+			if strings.Contains(msg, pat) {
+				return cls, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// retryBackoff returns the delay before the given (1 based) retry:
+// an exponential backoff, capped to MaxBackoff, with a random jitter
+// between 50% and 100% of the computed value.
+func retryBackoff(policy *templates.RetryPolicy, retry int) time.Duration {
+	base, top := defaultRetryInitialBackoff, defaultRetryMaxBackoff
+	if policy != nil {
+		if policy.InitialBackoff != nil {
+			base = policy.InitialBackoff.Duration
+		}
+		if policy.MaxBackoff != nil {
+			top = policy.MaxBackoff.Duration
+		}
+	}
+
+	d := base
+	for i := 1; i < retry && d < top; i++ {
+		d *= 2
+	}
+	d = min(d, top)
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
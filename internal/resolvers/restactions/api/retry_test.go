@@ -0,0 +1,218 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/krateoplatformops/plumbing/endpoints"
+	httpcall "github.com/krateoplatformops/plumbing/http/request"
+	"github.com/krateoplatformops/plumbing/http/response"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestShouldRetry(t *testing.T) {
+	failure := response.New(http.StatusInternalServerError, fmt.Errorf("transport failure"))
+
+	tests := []struct {
+		name      string
+		policy    *templates.RetryPolicy
+		status    *response.Status
+		transport error
+		want      bool
+	}{
+		{
+			name:   "no policy",
+			status: response.New(http.StatusBadGateway, fmt.Errorf("bad gateway")),
+			want:   false,
+		},
+		{
+			name:   "default status codes",
+			policy: &templates.RetryPolicy{MaxAttempts: 3},
+			status: response.New(http.StatusBadGateway, fmt.Errorf("bad gateway")),
+			want:   true,
+		},
+		{
+			name:   "default errors",
+			policy: &templates.RetryPolicy{MaxAttempts: 3},
+			status: failure,
+			transport: &url.Error{Op: "Get", URL: "http://example.com",
+				Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}},
+			want: true,
+		},
+		{
+			name:      "timeout",
+			policy:    &templates.RetryPolicy{MaxAttempts: 3},
+			status:    failure,
+			transport: &url.Error{Op: "Post", URL: "http://example.com", Err: context.DeadlineExceeded},
+			want:      true,
+		},
+		{
+			name:   "response body is not classified",
+			policy: &templates.RetryPolicy{MaxAttempts: 3},
+			status: response.New(http.StatusBadRequest,
+				fmt.Errorf("upstream timeout: connection reset, unexpected EOF")),
+			want: false,
+		},
+		{
+			name:   "not matching status code",
+			policy: &templates.RetryPolicy{MaxAttempts: 3},
+			status: response.New(http.StatusNotFound, fmt.Errorf("not found")),
+			want:   false,
+		},
+		{
+			name: "explicit status codes only",
+			policy: &templates.RetryPolicy{
+				MaxAttempts: 3, StatusCodes: []int{http.StatusConflict},
+			},
+			status:    failure,
+			transport: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+			want:      false,
+		},
+		{
+			name: "explicit error classes",
+			policy: &templates.RetryPolicy{
+				MaxAttempts: 3, Errors: []templates.RetryErrorClass{templates.RetryOnDNS},
+			},
+			status: failure,
+			transport: &url.Error{Op: "Get", URL: "http://nowhere",
+				Err: &net.DNSError{Err: "no such host", Name: "nowhere", IsNotFound: true}},
+			want: true,
+		},
+		{
+			name:   "success",
+			policy: &templates.RetryPolicy{MaxAttempts: 3},
+			status: response.New(http.StatusOK, nil),
+			want:   false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := shouldRetry(tc.policy, tc.status, tc.transport); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := &templates.RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: &metav1.Duration{Duration: 100 * time.Millisecond},
+		MaxBackoff:     &metav1.Duration{Duration: 300 * time.Millisecond},
+	}
+
+	for retry, top := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 300 * time.Millisecond,
+		4: 300 * time.Millisecond,
+	} {
+		got := retryBackoff(policy, retry)
+		if got < top/2 || got > top {
+			t.Errorf("retry %d: expected backoff in [%s, %s], got %s", retry, top/2, top, got)
+		}
+	}
+}
+
+func TestDoCallWithRetry(t *testing.T) {
+	// disable the HTTP client own retries
+	t.Setenv("CLIENT_MAX_RETRIES", "0")
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("try later"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	policy := &templates.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: &metav1.Duration{Duration: time.Millisecond},
+	}
+
+	res := doCall(context.Background(), callOptions{
+		id: "test",
+		call: httpcall.RequestOptions{
+			RequestInfo: httpcall.RequestInfo{Path: "/", Verb: ptr.To(http.MethodPost)},
+			Endpoint:    &endpoints.Endpoint{ServerURL: srv.URL},
+		},
+		retry: policy,
+	})
+	if res.failed() {
+		t.Fatalf("unexpected failure: %v", res.status)
+	}
+	if got := hits.Load(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+	if !deepEqual(res.out["test"], map[string]any{"ok": true}) {
+		t.Errorf("unexpected result: %v", res.out)
+	}
+
+	hits.Store(0)
+	policy.MaxAttempts = 2
+	res = doCall(context.Background(), callOptions{
+		id: "test",
+		call: httpcall.RequestOptions{
+			RequestInfo: httpcall.RequestInfo{Path: "/", Verb: ptr.To(http.MethodPost)},
+			Endpoint:    &endpoints.Endpoint{ServerURL: srv.URL},
+		},
+		retry: policy,
+	})
+	if !res.failed() || res.status.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected failure with code 503, got: %v", res.status)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestDoCallNoRetryOnResponseBody(t *testing.T) {
+	// disable the HTTP client own retries
+	t.Setenv("CLIENT_MAX_RETRIES", "0")
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("upstream timeout: connection reset by peer"))
+	}))
+	defer srv.Close()
+
+	res := doCall(context.Background(), callOptions{
+		id: "test",
+		call: httpcall.RequestOptions{
+			RequestInfo: httpcall.RequestInfo{Path: "/", Verb: ptr.To(http.MethodPost)},
+			Endpoint:    &endpoints.Endpoint{ServerURL: srv.URL},
+		},
+		retry: &templates.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: &metav1.Duration{Duration: time.Millisecond},
+		},
+	})
+	if !res.failed() || res.status.Code != http.StatusBadRequest {
+		t.Fatalf("expected failure with code 400, got: %v", res.status)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+}
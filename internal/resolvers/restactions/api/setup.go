@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	httpcall "github.com/krateoplatformops/plumbing/http/request"
 	"github.com/krateoplatformops/plumbing/jqutil"
@@ -12,28 +13,43 @@ import (
 	jqsupport "github.com/krateoplatformops/snowplow/internal/support/jq"
 )
 
-func createRequestOptions(log *slog.Logger, in *templates.API, dict map[string]any) (all []httpcall.RequestOptions) {
-	it := ""
-	if in.DependsOn != nil {
-		it = ptr.Deref(in.DependsOn.Iterator, "")
+func createRequestOptions(log *slog.Logger, in *templates.API, dict map[string]any, env map[string]string) (all []httpcall.RequestOptions) {
+	for _, ds := range iterationSources(log, in, dict) {
+		all = append(all, createRequestOption(in, ds, env))
 	}
+	return all
+}
 
-	if len(it) == 0 {
-		all = make([]httpcall.RequestOptions, 0, 1)
-		el := createRequestOption(in, dict)
-		all = append(all, el)
+// source is the data on which the API fields are evaluated: the whole
+// dict, with the iterator element (if any) bound as $item and $index.
+type source struct {
+	data any
+	vars []jqsupport.Var
+}
+
+// iterationSources returns the data sources on which the API fields are evaluated:
+// one for each element returned by the iterator, if any, otherwise the whole dict.
+func iterationSources(log *slog.Logger, in *templates.API, dict map[string]any) (all []source) {
+	it, err := iteratorOf(in)
+	if err != nil {
+		log.Error("unable to get iterator", slog.String("name", in.Name), slog.Any("err", err))
 		return
 	}
 
-	all = []httpcall.RequestOptions{}
+	if len(it) == 0 {
+		return []source{{data: dict}}
+	}
+
+	all = []source{}
 
 	action := func(sa any) error {
-		el := createRequestOption(in, sa)
-		all = append(all, el)
+		all = append(all, source{
+			data: dict, vars: jqsupport.IterationVars(sa, len(all)),
+		})
 		return nil
 	}
 
-	err := jqutil.ForEach(context.TODO(), jqutil.EvalOptions{Query: it, Unquote: true, Data: dict}, action)
+	err = jqutil.ForEach(context.TODO(), jqutil.EvalOptions{Query: it, Unquote: true, Data: dict}, action)
 	if err != nil {
 		log.Error("unable to execute iterator", slog.String("query", it), slog.Any("err", err))
 	}
@@ -41,39 +57,46 @@ func createRequestOptions(log *slog.Logger, in *templates.API, dict map[string]a
 	return all
 }
 
-func createRequestOption(in *templates.API, ds any) (out httpcall.RequestOptions) {
+// createRequestOption evaluates the API templates; the env $(NAME) references
+// are expanded in the templates only, never in the evaluated data.
+func createRequestOption(in *templates.API, ds source, env map[string]string) (out httpcall.RequestOptions) {
 	out.ContinueOnError = ptr.Deref(in.ContinueOnError, false)
 	out.ErrorKey = ptr.Deref(in.ErrorKey, "error")
 
-	out.Path = evalJQ(in.Path, ds)
+	out.Path = evalJQ(expandTemplate(in.Path, env), ds)
 	out.Verb = ptr.To(ptr.Deref(in.Verb, http.MethodGet))
 
 	if in.Payload != nil {
-		out.Payload = ptr.To(evalJQ(*in.Payload, ds))
+		out.Payload = ptr.To(evalJQ(expandTemplate(*in.Payload, env), ds))
 	}
 
 	if in.Headers != nil {
 		out.Headers = make([]string, 0, len(in.Headers))
 		//copy(el.Headers, in.Headers)
 		for _, h := range in.Headers {
-			out.Headers = append(out.Headers, evalJQ(h, ds))
+			out.Headers = append(out.Headers, evalJQ(expandTemplate(h, env), ds))
 		}
 	}
 
 	return
 }
 
-func evalJQ(q string, ds any) string {
+func evalJQ(q string, ds source) string {
 	q, ok := jqutil.MaybeQuery(q)
 	if !ok {
 		return q
 	}
 
+	q, data, err := jqsupport.Bind(q, ds.data, ds.vars...)
+	if err != nil {
+		return err.Error()
+	}
+
 	out, err := jqutil.Eval(context.TODO(),
 		jqutil.EvalOptions{
 			Query:        q,
 			Unquote:      true,
-			Data:         ds,
+			Data:         data,
 			ModuleLoader: jqsupport.ModuleLoader(),
 		})
 	if err != nil {
@@ -82,3 +105,26 @@ func evalJQ(q string, ds any) string {
 
 	return out
 }
+
+// evalCondition evaluates the JQ expression (optionally wrapped in `${ }`)
+// and returns false if the result is false or null.
+func evalCondition(q string, ds any) (bool, error) {
+	q, _ = jqutil.MaybeQuery(q)
+
+	out, err := jqutil.Eval(context.TODO(),
+		jqutil.EvalOptions{
+			Query:        q,
+			Data:         ds,
+			ModuleLoader: jqsupport.ModuleLoader(),
+		})
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.TrimSpace(out) {
+	case "", "false", "null":
+		return false, nil
+	default:
+		return true, nil
+	}
+}
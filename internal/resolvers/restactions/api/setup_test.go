@@ -22,15 +22,15 @@ func Example_createRequestOptions() {
 
 	all := createRequestOptions(logger, &templates.API{
 		Name: "example",
-		Path: `${ "/api/v1/namespaces/" + (.) + "/pods" }`,
+		Path: `${ "/api/v1/namespaces/" + $item + "/pods" }`,
 		DependsOn: &templates.Dependency{
-			Name:     "namespaces",
-			Iterator: ptr.To(".[]"),
+			Name: "namespaces", Iterator: ptr.To(".namespaces"),
 		},
 		Headers: []string{
-			`${ "X-Namespace: " + (.) }`,
+			`${ "X-Namespace: " + $item }`,
+			`${ "X-Index: " + ($index|tostring) + "/" + (.namespaces|length|tostring) }`,
 		},
-	}, dict)
+	}, dict, nil)
 
 	for _, el := range all {
 		fmt.Println(ptr.Deref(el.Verb, http.MethodGet), el.Path)
@@ -42,10 +42,13 @@ func Example_createRequestOptions() {
 	// Output:
 	// GET /api/v1/namespaces/demo-system/pods
 	//   X-Namespace: demo-system
+	//   X-Index: 0/3
 	// GET /api/v1/namespaces/krateo-system/pods
 	//   X-Namespace: krateo-system
+	//   X-Index: 1/3
 	// GET /api/v1/namespaces/example-system/pods
 	//   X-Namespace: example-system
+	//   X-Index: 2/3
 }
 
 func Example_createRequestOptions_no_iter() {
@@ -62,7 +65,7 @@ func Example_createRequestOptions_no_iter() {
 		DependsOn: &templates.Dependency{
 			Name: "namespaces",
 		},
-	}, dict)
+	}, dict, nil)
 
 	for _, el := range all {
 		fmt.Println(ptr.Deref(el.Verb, http.MethodGet), el.Path)
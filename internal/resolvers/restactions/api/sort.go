@@ -2,23 +2,49 @@ package api
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/krateoplatformops/plumbing/ptr"
 	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
 )
 
 func topologicalSort(items []*templates.API) ([]string, error) {
+	levels, err := topologicalLevels(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var sortedItems []string
+	for _, level := range levels {
+		sortedItems = append(sortedItems, level...)
+	}
+
+	return sortedItems, nil
+}
+
+// topologicalLevels groups the API names by dependency level: every API
+// in a level depends only on APIs belonging to previous levels, so all the
+// APIs of the same level can be resolved concurrently.
+func topologicalLevels(items []*templates.API) ([][]string, error) {
 	graph := make(map[string][]string)
 	inDegree := make(map[string]int)
 	itemSet := make(map[string]bool)
 
 	for _, item := range items {
 		itemSet[item.Name] = true
+	}
 
-		if item.DependsOn == nil {
-			continue
+	for _, item := range items {
+		if _, err := iteratorOf(item); err != nil {
+			return nil, err
 		}
 
-		if dep := item.DependsOn.Name; len(dep) > 0 {
+		for _, dep := range dependencyNames(item) {
+			if !itemSet[dep] {
+				return nil, fmt.Errorf("api %q depends on unknown api %q", item.Name, dep)
+			}
+
 			graph[dep] = append(graph[dep], item.Name)
 			inDegree[item.Name]++
 		}
@@ -31,23 +57,64 @@ func topologicalSort(items []*templates.API) ([]string, error) {
 		}
 	}
 
-	var sortedItems []string
+	total := 0
+	var levels [][]string
 	for len(queue) > 0 {
-		item := queue[0]
-		queue = queue[1:]
-		sortedItems = append(sortedItems, item)
-
-		for _, dependent := range graph[item] {
-			inDegree[dependent]--
-			if inDegree[dependent] == 0 {
-				queue = append(queue, dependent)
+		sort.Strings(queue)
+		levels = append(levels, queue)
+		total += len(queue)
+
+		var next []string
+		for _, item := range queue {
+			for _, dependent := range graph[item] {
+				inDegree[dependent]--
+				if inDegree[dependent] == 0 {
+					next = append(next, dependent)
+				}
 			}
 		}
+		queue = next
 	}
 
-	if len(sortedItems) != len(itemSet) {
-		return nil, fmt.Errorf("cyclic dependency detected")
+	if total != len(itemSet) {
+		var cyclic []string
+		for item := range itemSet {
+			if inDegree[item] > 0 {
+				cyclic = append(cyclic, item)
+			}
+		}
+		sort.Strings(cyclic)
+
+		return nil, fmt.Errorf("cyclic dependency detected (%s)", strings.Join(cyclic, ", "))
 	}
 
-	return sortedItems, nil
+	return levels, nil
+}
+
+// dependencyNames returns the (unique) names of the APIs on which the given one depends.
+func dependencyNames(in *templates.API) []string {
+	return in.DependsOn.All()
+}
+
+// iteratorOf returns the iterator declared by the API dependency;
+// it iterates over the result of the dependency name.
+func iteratorOf(in *templates.API) (string, error) {
+	if in.DependsOn == nil {
+		return "", nil
+	}
+
+	it := ptr.Deref(in.DependsOn.Iterator, "")
+	if len(it) > 0 && len(in.DependsOn.Name) == 0 {
+		return "", fmt.Errorf("api %q declares an iterator without the dependency name", in.Name)
+	}
+
+	return it, nil
+}
+
+// iteratedDependency returns the name of the dependency declaring the iterator (if any).
+func iteratedDependency(in *templates.API) string {
+	if in.DependsOn == nil || len(ptr.Deref(in.DependsOn.Iterator, "")) == 0 {
+		return ""
+	}
+	return in.DependsOn.Name
 }
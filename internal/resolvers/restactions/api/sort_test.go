@@ -6,7 +6,9 @@ package api
 import (
 	"fmt"
 	"os"
+	"testing"
 
+	"github.com/krateoplatformops/plumbing/ptr"
 	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
 )
 
@@ -26,3 +28,97 @@ func Example_topologicalSort() {
 	// Output:
 	// [api4 api3 api1]
 }
+
+func Example_topologicalLevels() {
+	got, err := topologicalLevels([]*templates.API{
+		{Name: "pods", DependsOn: &templates.Dependency{Name: "namespaces"}},
+		{Name: "namespaces"},
+		{Name: "users"},
+		{Name: "projects"},
+		{Name: "events", DependsOn: &templates.Dependency{Name: "pods"}},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v", err)
+		return
+	}
+
+	fmt.Println(got)
+
+	// Output:
+	// [[namespaces projects users] [pods] [events]]
+}
+
+func Example_topologicalLevels_multipleDependencies() {
+	got, err := topologicalLevels([]*templates.API{
+		{Name: "report", DependsOn: &templates.Dependency{
+			Names: []string{"users", "projects"},
+		}},
+		{Name: "users"},
+		{Name: "projects", DependsOn: &templates.Dependency{Name: "teams"}},
+		{Name: "teams"},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v", err)
+		return
+	}
+
+	fmt.Println(got)
+
+	// Output:
+	// [[teams users] [projects] [report]]
+}
+
+func TestTopologicalLevelsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []*templates.API
+		want  string
+	}{
+		{
+			name: "cycle",
+			items: []*templates.API{
+				{Name: "a", DependsOn: &templates.Dependency{Name: "b"}},
+				{Name: "b", DependsOn: &templates.Dependency{Name: "c", Names: []string{"d"}}},
+				{Name: "c", DependsOn: &templates.Dependency{Name: "a"}},
+				{Name: "d"},
+			},
+			want: "cyclic dependency detected (a, b, c)",
+		},
+		{
+			name: "self dependency",
+			items: []*templates.API{
+				{Name: "a", DependsOn: &templates.Dependency{Name: "a"}},
+			},
+			want: "cyclic dependency detected (a)",
+		},
+		{
+			name: "unknown dependency",
+			items: []*templates.API{
+				{Name: "a", DependsOn: &templates.Dependency{Name: "b"}},
+			},
+			want: `api "a" depends on unknown api "b"`,
+		},
+		{
+			name: "iterator without name",
+			items: []*templates.API{
+				{Name: "a"},
+				{Name: "c", DependsOn: &templates.Dependency{
+					Names: []string{"a"}, Iterator: ptr.To(".a"),
+				}},
+			},
+			want: `api "c" declares an iterator without the dependency name`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := topologicalLevels(tc.items)
+			if err == nil {
+				t.Fatalf("expected error %q, got nil", tc.want)
+			}
+			if got := err.Error(); got != tc.want {
+				t.Errorf("expected error %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package restactions
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/api"
+)
+
+// StepsError is returned when the resolution has been halted by an API
+// failure not allowed by continueOnError; the RESTAction status holds
+// the partial results.
+type StepsError struct {
+	// Partial is true if at least one API has been resolved successfully.
+	Partial bool
+	// Steps are the failed APIs.
+	Steps []api.Outcome
+}
+
+func (e *StepsError) Error() string {
+	all := make([]string, 0, len(e.Steps))
+	for _, el := range e.Steps {
+		all = append(all, fmt.Sprintf("api %q failed (%d): %s", el.API, el.Code, el.Message))
+	}
+	return strings.Join(all, "; ")
+}
+
+// Code returns the aggregate HTTP status code: 207 (Multi-Status) for
+// partial results, 502 (Bad Gateway) if no API has been resolved.
+func (e *StepsError) Code() int {
+	if e.Partial {
+		return http.StatusMultiStatus
+	}
+	return http.StatusBadGateway
+}
+
+func stepsError(all []api.Outcome) *StepsError {
+	if !api.Halted(all) {
+		return nil
+	}
+
+	res := &StepsError{Steps: api.Failed(all)}
+	for _, el := range all {
+		if el.State == api.OutcomeSuccess {
+			res.Partial = true
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,71 @@
+//go:build unit
+// +build unit
+
+package restactions
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/api"
+)
+
+func TestStepsError(t *testing.T) {
+	failed := api.Outcome{API: "b", State: api.OutcomeFailed, Code: http.StatusNotFound, Message: "not here"}
+
+	tests := []struct {
+		name    string
+		all     []api.Outcome
+		halted  bool
+		code    int
+		message string
+	}{
+		{
+			name: "success",
+			all:  []api.Outcome{{API: "a", State: api.OutcomeSuccess}},
+		},
+		{
+			name: "continue on error",
+			all: []api.Outcome{
+				{API: "a", State: api.OutcomeSuccess},
+				{API: "b", State: api.OutcomeFailed, ContinueOnError: true},
+			},
+		},
+		{
+			name:    "partial",
+			all:     []api.Outcome{{API: "a", State: api.OutcomeSuccess}, failed},
+			halted:  true,
+			code:    http.StatusMultiStatus,
+			message: `api "b" failed (404): not here`,
+		},
+		{
+			name:    "no results",
+			all:     []api.Outcome{failed, {API: "c", State: api.OutcomeSkipped}},
+			halted:  true,
+			code:    http.StatusBadGateway,
+			message: `api "b" failed (404): not here`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := stepsError(tc.all)
+			if !tc.halted {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if got := err.Code(); got != tc.code {
+				t.Errorf("expected code %d, got %d", tc.code, got)
+			}
+			if got := err.Error(); got != tc.message {
+				t.Errorf("expected message %q, got %q", tc.message, got)
+			}
+		})
+	}
+}
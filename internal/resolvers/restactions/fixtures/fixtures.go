@@ -0,0 +1,326 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// EnvDir is the environment variable holding the fixtures directory.
+	EnvDir = "FIXTURES_DIR"
+	// EnvMode is the environment variable holding the default fixtures mode.
+	EnvMode = "FIXTURES_MODE"
+	// EnvOverride is the environment variable enabling the per request mode
+	// override (the fixtures query parameter); meant for development only.
+	EnvOverride = "FIXTURES_OVERRIDE"
+
+	// InternalEndpoint is the key of the user cluster endpoint
+	// (used by the APIs without endpointRef).
+	InternalEndpoint = "@internal"
+)
+
+// Mode selects whether the HTTP exchanges are recorded or replayed.
+type Mode string
+
+const (
+	ModeOff    Mode = "off"
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// ParseMode parses the mode name; the empty string means off.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "", ModeOff:
+		return ModeOff, nil
+	case ModeRecord, ModeReplay:
+		return m, nil
+	default:
+		return ModeOff, fmt.Errorf("invalid fixtures mode %q (allowed: record, replay, off)", s)
+	}
+}
+
+// Exchange is a recorded HTTP request and its response.
+type Exchange struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// Body is the request body.
+	Body   string      `json:"body,omitempty"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	// Response is the response body.
+	Response string `json:"response"`
+}
+
+func (e *Exchange) key() string {
+	return e.Method + " " + e.URL + "\n" + e.Body
+}
+
+// Fixture holds the HTTP exchanges performed resolving a RESTAction.
+type Fixture struct {
+	// Endpoints are the server URLs of the referenced endpoints (namespace/name).
+	Endpoints map[string]string `json:"endpoints,omitempty"`
+	Exchanges []Exchange        `json:"exchanges"`
+	// Status is the RESTAction status resolved while recording.
+	Status any `json:"status,omitempty"`
+}
+
+// Path returns the fixture file of the RESTAction recorded by the user:
+// the fixtures of each user are kept apart, so that the responses received
+// with the credentials of a user are never replayed to another one.
+func Path(dir, user, namespace, name string) (string, error) {
+	user = url.PathEscape(user)
+	if len(user) == 0 || user == "." || user == ".." {
+		return "", fmt.Errorf("invalid fixtures user %q", user)
+	}
+	return filepath.Join(dir, user, fmt.Sprintf("%s.%s.json", namespace, name)), nil
+}
+
+func Load(path string) (Fixture, error) {
+	var res Fixture
+
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return res, err
+	}
+
+	err = json.Unmarshal(dat, &res)
+	return res, err
+}
+
+// Save writes the fixture readable by the owner only:
+// the recorded responses may hold sensitive data.
+func Save(path string, f Fixture) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	dat, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, dat, 0o600)
+}
+
+// droppedHeaders are the response headers never recorded.
+var droppedHeaders = []string{"Set-Cookie"}
+
+// Session records or replays the HTTP exchanges of a resolution;
+// it is safe for concurrent use. A nil Session does nothing.
+type Session struct {
+	mode Mode
+
+	mu        sync.Mutex
+	endpoints map[string]string
+	exchanges []Exchange
+	status    any
+	// served counts the replayed responses of each request.
+	served map[string]int
+}
+
+// NewRecorder returns a session recording the exchanges.
+func NewRecorder() *Session {
+	return &Session{mode: ModeRecord, endpoints: map[string]string{}}
+}
+
+// NewPlayer returns a session replaying the fixture exchanges.
+func NewPlayer(f Fixture) *Session {
+	res := &Session{
+		mode:      ModeReplay,
+		endpoints: f.Endpoints,
+		exchanges: f.Exchanges,
+		served:    map[string]int{},
+	}
+	if res.endpoints == nil {
+		res.endpoints = map[string]string{}
+	}
+	return res
+}
+
+func (s *Session) Recording() bool {
+	return s != nil && s.mode == ModeRecord
+}
+
+func (s *Session) Replaying() bool {
+	return s != nil && s.mode == ModeReplay
+}
+
+// SetEndpoint records the server URL of the endpoint.
+func (s *Session) SetEndpoint(key, serverURL string) {
+	if !s.Recording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints[key] = serverURL
+}
+
+// Endpoint returns the recorded server URL of the endpoint.
+func (s *Session) Endpoint(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	url, ok := s.endpoints[key]
+	if !ok {
+		return "", fmt.Errorf("endpoint %q not found in fixture", key)
+	}
+	return url, nil
+}
+
+// SetStatus records the resolved RESTAction status.
+func (s *Session) SetStatus(status any) {
+	if !s.Recording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// Fixture returns a snapshot of the recorded data.
+func (s *Session) Fixture() Fixture {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return Fixture{
+		Endpoints: s.endpoints,
+		Exchanges: append([]Exchange{}, s.exchanges...),
+		Status:    s.status,
+	}
+}
+
+// Transport wraps the round tripper: in record mode the exchanges are
+// recorded, in replay mode they are served from the fixture without
+// calling next. The optional restore function is applied to the recorded
+// URL, request and response bodies (i.e. to hide secret values).
+func (s *Session) Transport(next http.RoundTripper, restore func(string) string) http.RoundTripper {
+	if s == nil || s.mode == ModeOff {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if restore == nil {
+		restore = func(v string) string { return v }
+	}
+
+	return &transport{s: s, next: next, restore: restore}
+}
+
+type transport struct {
+	s       *Session
+	next    http.RoundTripper
+	restore func(string) string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	el := Exchange{
+		Method: req.Method,
+		URL:    t.restore(req.URL.String()),
+		Body:   t.restore(string(body)),
+	}
+
+	if t.s.mode == ModeReplay {
+		return t.s.replay(req, el)
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	dat, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(dat))
+
+	el.Status = res.StatusCode
+	el.Header = res.Header.Clone()
+	for _, k := range droppedHeaders {
+		el.Header.Del(k)
+	}
+	el.Response = t.restore(string(dat))
+
+	t.s.mu.Lock()
+	t.s.exchanges = append(t.s.exchanges, el)
+	t.s.mu.Unlock()
+
+	return res, nil
+}
+
+// replay serves the recorded responses of the request following the
+// recording order; the last one is repeated once all have been served.
+func (s *Session) replay(req *http.Request, in Exchange) (*http.Response, error) {
+	key := in.key()
+
+	s.mu.Lock()
+	all := []Exchange{}
+	for _, el := range s.exchanges {
+		if el.key() == key {
+			all = append(all, el)
+		}
+	}
+	idx := s.served[key]
+	s.served[key] = idx + 1
+	s.mu.Unlock()
+
+	if len(all) == 0 {
+		return nil, fmt.Errorf("no recorded response for %s %s", in.Method, in.URL)
+	}
+
+	el := all[min(idx, len(all)-1)]
+
+	header := el.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", el.Status, http.StatusText(el.Status)),
+		StatusCode:    el.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(el.Response)),
+		ContentLength: int64(len(el.Response)),
+		Request:       req,
+	}, nil
+}
+
+type sessionKey struct{}
+
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// FromContext returns the session carried by the context (nil if none).
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
@@ -0,0 +1,171 @@
+//go:build unit
+// +build unit
+
+package fixtures
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Mode
+		err  bool
+	}{
+		{in: "", want: ModeOff},
+		{in: "off", want: ModeOff},
+		{in: " Record ", want: ModeRecord},
+		{in: "replay", want: ModeReplay},
+		{in: "rewind", want: ModeOff, err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseMode(tc.in)
+			if (err != nil) != tc.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		dat, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Write([]byte(`{"hit":` + string(rune('0'+hits)) + `,"body":"` + string(dat) + `"}`))
+	}))
+
+	restore := func(s string) string {
+		return strings.ReplaceAll(s, "s3cr3t", "$(TOKEN)")
+	}
+
+	rec := NewRecorder()
+	rec.SetEndpoint("demo/httpbin", srv.URL)
+	cli := &http.Client{Transport: rec.Transport(nil, restore)}
+
+	for range 2 {
+		res, err := cli.Post(srv.URL+"/items?key=s3cr3t", "application/json", strings.NewReader("s3cr3t"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	rec.SetStatus(map[string]any{"ok": true})
+	srv.Close()
+
+	path, err := Path(t.TempDir(), "system:serviceaccount:demo/ci", "demo", "items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, rec.Fixture()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := filepath.Base(path), "demo.items.json"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got, want := filepath.Base(filepath.Dir(path)), "system:serviceaccount:demo%2Fci"; got != want {
+		t.Fatalf("expected user directory %q, got %q", want, got)
+	}
+	for p, want := range map[string]os.FileMode{path: 0o600, filepath.Dir(path): 0o700} {
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fi.Mode().Perm(); got != want {
+			t.Fatalf("expected %s mode %v, got %v", filepath.Base(p), want, got)
+		}
+	}
+	if _, err := Path(t.TempDir(), "..", "demo", "items"); err == nil {
+		t.Fatalf("expected invalid user error")
+	}
+
+	fix, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(fix.Exchanges); got != 2 {
+		t.Fatalf("expected 2 exchanges, got %d", got)
+	}
+	for _, el := range fix.Exchanges {
+		if strings.Contains(el.URL, "s3cr3t") || strings.Contains(el.Body, "s3cr3t") {
+			t.Fatalf("secret value recorded: %+v", el)
+		}
+		if len(el.Header.Get("Set-Cookie")) > 0 {
+			t.Fatalf("Set-Cookie header recorded")
+		}
+	}
+	if fix.Status == nil {
+		t.Fatalf("expected recorded status")
+	}
+
+	player := NewPlayer(fix)
+	if got, err := player.Endpoint("demo/httpbin"); err != nil || got != srv.URL {
+		t.Fatalf("expected endpoint %q, got %q (%v)", srv.URL, got, err)
+	}
+	if _, err := player.Endpoint("demo/unknown"); err == nil {
+		t.Fatalf("expected error for unknown endpoint")
+	}
+
+	cli = &http.Client{Transport: player.Transport(nil, restore)}
+
+	want := []string{
+		`{"hit":1,"body":"$(TOKEN)"}`,
+		`{"hit":2,"body":"$(TOKEN)"}`,
+		`{"hit":2,"body":"$(TOKEN)"}`,
+	}
+	for i, w := range want {
+		res, err := cli.Post(srv.URL+"/items?key=s3cr3t", "application/json", strings.NewReader("s3cr3t"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dat, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if got := string(dat); got != w {
+			t.Fatalf("replay %d: expected %s, got %s", i, w, got)
+		}
+		if got := res.Header.Get("Content-Type"); got != "application/json" {
+			t.Fatalf("replay %d: expected recorded content type, got %q", i, got)
+		}
+	}
+
+	_, err = cli.Get(srv.URL + "/unknown")
+	if err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Fatalf("expected unmatched request error, got: %v", err)
+	}
+}
+
+func TestSessionContext(t *testing.T) {
+	if FromContext(context.Background()) != nil {
+		t.Fatalf("expected no session")
+	}
+
+	var off *Session
+	if off.Recording() || off.Replaying() {
+		t.Fatalf("nil session must be off")
+	}
+	if rt := off.Transport(http.DefaultTransport, nil); rt != http.DefaultTransport {
+		t.Fatalf("nil session must not wrap the transport")
+	}
+
+	sess := NewRecorder()
+	if got := FromContext(WithSession(context.Background(), sess)); got != sess {
+		t.Fatalf("expected session from context")
+	}
+}
@@ -0,0 +1,68 @@
+//go:build unit
+// +build unit
+
+package restactions
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	crdschema "github.com/krateoplatformops/snowplow/internal/resolvers/crds/schema"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestResolveOutputSchema(t *testing.T) {
+	schema := &runtime.RawExtension{Raw: []byte(`{
+		"type": "object",
+		"required": ["names"],
+		"properties": {
+			"names": {"type": "array", "items": {"type": "string"}}
+		}
+	}`)}
+
+	tests := []struct {
+		name   string
+		filter string
+		fields []string
+	}{
+		{name: "valid", filter: `{names: ["a", "b"]}`},
+		{name: "invalid", filter: `{names: [1], total: 1}`,
+			fields: []string{"status", "status.names[0]"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := &templates.RESTAction{}
+			in.Name, in.Namespace = "demo", "test"
+			in.Spec.Filter = ptr.To(tc.filter)
+			in.Spec.OutputSchema = schema
+
+			res, err := Resolve(context.Background(), ResolveOptions{In: in})
+			if len(tc.fields) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var verr *crdschema.ViolationsError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected violations, got: %v", err)
+			}
+			if len(verr.Violations) != len(tc.fields) {
+				t.Fatalf("expected %d violations, got %v", len(tc.fields), verr.Violations)
+			}
+			for i, el := range verr.Violations {
+				if el.Field != tc.fields[i] {
+					t.Errorf("[%d] expected field %q, got %q", i, tc.fields[i], el.Field)
+				}
+			}
+			if res.Status == nil {
+				t.Errorf("expected the resolved status")
+			}
+		})
+	}
+}
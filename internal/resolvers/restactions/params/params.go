@@ -0,0 +1,229 @@
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/krateoplatformops/plumbing/maps"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+)
+
+// Invalid is an input parameter not matching its declaration.
+type Invalid struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Error is returned when the extras do not match the declared params.
+type Error struct {
+	Params []Invalid
+}
+
+func (e *Error) Error() string {
+	all := make([]string, 0, len(e.Params))
+	for _, el := range e.Params {
+		all = append(all, fmt.Sprintf("%s: %s", el.Name, el.Reason))
+	}
+	return "invalid params: " + strings.Join(all, "; ")
+}
+
+// Apply validates the extras against the declared params and returns
+// a copy with the defaults set and the values converted to the declared
+// types (i.e. "5" to 5 for number params); the extras not declared
+// are left untouched.
+func Apply(params []templates.Param, extras map[string]any) (map[string]any, error) {
+	res := map[string]any{}
+	if extras != nil {
+		res = maps.DeepCopyJSON(extras)
+	}
+
+	if len(params) == 0 {
+		return res, nil
+	}
+
+	var invalid []Invalid
+	for _, p := range params {
+		val, ok := res[p.Name]
+		if !ok || val == nil {
+			def, err := Default(p)
+			if err != nil {
+				invalid = append(invalid, Invalid{Name: p.Name, Reason: err.Error()})
+				continue
+			}
+			if def == nil {
+				if p.Required {
+					invalid = append(invalid, Invalid{Name: p.Name, Reason: "required"})
+				}
+				continue
+			}
+			res[p.Name] = def
+			continue
+		}
+
+		val, err := Value(p, val)
+		if err != nil {
+			invalid = append(invalid, Invalid{Name: p.Name, Reason: err.Error()})
+			continue
+		}
+		res[p.Name] = val
+	}
+
+	if len(invalid) > 0 {
+		return res, &Error{Params: invalid}
+	}
+	return res, nil
+}
+
+// Default returns the validated default value of the param (nil if none).
+func Default(p templates.Param) (any, error) {
+	if p.Default == nil || len(p.Default.Raw) == 0 {
+		return nil, nil
+	}
+
+	var val any
+	if err := json.Unmarshal(p.Default.Raw, &val); err != nil {
+		return nil, fmt.Errorf("invalid default: %w", err)
+	}
+	if val == nil {
+		return nil, nil
+	}
+
+	val, err := Value(p, val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default: %w", err)
+	}
+	return val, nil
+}
+
+// Value converts the value to the param type and checks the enum and pattern constraints.
+func Value(p templates.Param, val any) (any, error) {
+	typ := p.Type
+	if len(typ) == 0 {
+		typ = templates.ParamTypeString
+	}
+
+	val, err := convert(typ, val)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.Enum) > 0 {
+		s := enumValue(val)
+		if !slices.Contains(p.Enum, s) {
+			return nil, fmt.Errorf("value %q not allowed (allowed: %s)", s, strings.Join(p.Enum, ", "))
+		}
+	}
+
+	if p.Pattern != nil {
+		re, err := regexp.Compile(*p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		if s, ok := val.(string); ok && !re.MatchString(s) {
+			return nil, fmt.Errorf("value %q does not match pattern %q", s, *p.Pattern)
+		}
+	}
+
+	return val, nil
+}
+
+// convert returns the value as the given type; strings are parsed
+// for the integer, number and boolean types.
+func convert(typ templates.ParamType, val any) (any, error) {
+	switch typ {
+	case templates.ParamTypeString:
+		if s, ok := val.(string); ok {
+			return s, nil
+		}
+
+	case templates.ParamTypeInteger:
+		n, ok := number(val)
+		if ok && n == math.Trunc(n) {
+			return n, nil
+		}
+
+	case templates.ParamTypeNumber:
+		if n, ok := number(val); ok {
+			return n, nil
+		}
+
+	case templates.ParamTypeBoolean:
+		switch v := val.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+
+	case templates.ParamTypeArray:
+		if v, ok := val.([]any); ok {
+			return v, nil
+		}
+
+	case templates.ParamTypeObject:
+		if v, ok := val.(map[string]any); ok {
+			return v, nil
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+
+	return nil, fmt.Errorf("expected %s, got %s", typ, typeName(val))
+}
+
+// number returns the value as float64 (as decoded from JSON).
+func number(val any) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil && !math.IsNaN(n) && !math.IsInf(n, 0)
+	}
+	return 0, false
+}
+
+// enumValue returns the value as compared with the enum values.
+func enumValue(val any) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	dat, _ := json.Marshal(val)
+	return string(dat)
+}
+
+func typeName(val any) string {
+	switch val.(type) {
+	case string:
+		return "string"
+	case float64, int64, int:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", val)
+}
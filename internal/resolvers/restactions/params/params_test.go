@@ -0,0 +1,103 @@
+//go:build unit
+// +build unit
+
+package params
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestApply(t *testing.T) {
+	decl := []templates.Param{
+		{Name: "size", Type: templates.ParamTypeInteger, Default: &runtime.RawExtension{Raw: []byte(`20`)}},
+		{Name: "order", Enum: []string{"asc", "desc"}, Default: &runtime.RawExtension{Raw: []byte(`"asc"`)}},
+		{Name: "ns", Required: true, Pattern: ptr.To(`^[a-z0-9-]+$`)},
+		{Name: "verbose", Type: templates.ParamTypeBoolean},
+		{Name: "tags", Type: templates.ParamTypeArray},
+		{Name: "ratio", Type: templates.ParamTypeNumber, Enum: []string{"0.5", "1"}},
+	}
+
+	tests := []struct {
+		name    string
+		extras  map[string]any
+		want    map[string]any
+		invalid []string
+	}{
+		{
+			name:   "defaults",
+			extras: map[string]any{"ns": "demo", "other": "kept"},
+			want:   map[string]any{"ns": "demo", "other": "kept", "size": 20.0, "order": "asc"},
+		},
+		{
+			name: "conversions",
+			extras: map[string]any{
+				"ns": "demo", "size": "5", "verbose": "true", "tags": []any{"a"}, "ratio": 1.0,
+			},
+			want: map[string]any{
+				"ns": "demo", "size": 5.0, "order": "asc", "verbose": true, "tags": []any{"a"}, "ratio": 1.0,
+			},
+		},
+		{
+			name: "invalid",
+			extras: map[string]any{
+				"size": 1.5, "order": "random", "verbose": "maybe", "tags": "a", "ratio": "0.7",
+			},
+			invalid: []string{"size", "order", "ns", "verbose", "tags", "ratio"},
+		},
+		{
+			name:    "pattern",
+			extras:  map[string]any{"ns": "Demo_NS"},
+			invalid: []string{"ns"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Apply(decl, tc.extras)
+			if len(tc.invalid) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Fatalf("expected %v, got %v", tc.want, got)
+				}
+				return
+			}
+
+			var perr *Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected params error, got: %v", err)
+			}
+			names := make([]string, 0, len(perr.Params))
+			for _, el := range perr.Params {
+				names = append(names, el.Name)
+			}
+			if !reflect.DeepEqual(names, tc.invalid) {
+				t.Fatalf("expected invalid %v, got %v", tc.invalid, perr.Params)
+			}
+		})
+	}
+}
+
+func TestApplyNoParams(t *testing.T) {
+	extras := map[string]any{"any": map[string]any{"thing": 1.0}}
+
+	got, err := Apply(nil, extras)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, extras) {
+		t.Fatalf("expected %v, got %v", extras, got)
+	}
+
+	got["any"].(map[string]any)["thing"] = 2.0
+	if extras["any"].(map[string]any)["thing"] != 1.0 {
+		t.Fatalf("extras must not be modified")
+	}
+}
@@ -0,0 +1,94 @@
+package restactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/krateoplatformops/plumbing/http/response"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"github.com/krateoplatformops/snowplow/internal/objects"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/api"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/params"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// maxReferenceDepth is the maximum nesting level of RESTActions referenced by other RESTActions.
+const maxReferenceDepth = 8
+
+var errReferenceLoop = errors.New("restaction reference loop")
+
+type referenceChainKey struct{}
+
+// enterReferenceChain records the RESTAction in the chain of the RESTActions
+// being resolved, failing on cycles or when the chain is too deep.
+func enterReferenceChain(ctx context.Context, in *templates.RESTAction) (context.Context, error) {
+	chain, _ := ctx.Value(referenceChainKey{}).([]string)
+
+	id := fmt.Sprintf("%s/%s", in.Namespace, in.Name)
+	if slices.Contains(chain, id) {
+		return ctx, fmt.Errorf("%w: %s", errReferenceLoop,
+			strings.Join(append(slices.Clone(chain), id), " -> "))
+	}
+	if len(chain) >= maxReferenceDepth {
+		return ctx, fmt.Errorf("%w: maximum depth (%d) exceeded resolving %s",
+			errReferenceLoop, maxReferenceDepth, id)
+	}
+
+	return context.WithValue(ctx, referenceChainKey{}, append(slices.Clone(chain), id)), nil
+}
+
+// referenceResolver resolves the RESTActions referenced by the APIs
+// with the identity of the current user.
+func referenceResolver(opts ResolveOptions) api.RESTActionResolver {
+	return func(ctx context.Context, ref templates.Reference, extras map[string]any) (any, *response.Status) {
+		got := objects.Get(ctx, templates.ObjectReference{
+			Reference:  ref,
+			Resource:   "restactions",
+			APIVersion: templates.SchemeGroupVersion.String(),
+		})
+		if got.Err != nil {
+			return nil, got.Err
+		}
+
+		var ra templates.RESTAction
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(got.Unstructured.Object, &ra)
+		if err != nil {
+			return nil, response.New(http.StatusInternalServerError, err)
+		}
+
+		_, err = Resolve(ctx, ResolveOptions{
+			In:      &ra,
+			SArc:    opts.SArc,
+			AuthnNS: opts.AuthnNS,
+			Extras:  extras,
+		})
+		if err != nil {
+			if errors.Is(err, errReferenceLoop) {
+				return nil, response.New(http.StatusLoopDetected, err)
+			}
+
+			var failed *StepsError
+			if errors.As(err, &failed) {
+				return nil, response.New(http.StatusBadGateway, err)
+			}
+			if errors.As(err, new(*params.Error)) {
+				return nil, response.New(http.StatusBadRequest, err)
+			}
+			return nil, response.New(http.StatusInternalServerError, err)
+		}
+
+		var res any
+		if ra.Status != nil && len(ra.Status.Raw) > 0 {
+			if err := json.Unmarshal(ra.Status.Raw, &res); err != nil {
+				return nil, response.New(http.StatusInternalServerError, err)
+			}
+		}
+
+		return res, nil
+	}
+}
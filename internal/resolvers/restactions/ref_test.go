@@ -0,0 +1,52 @@
+//go:build unit
+// +build unit
+
+package restactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEnterReferenceChain(t *testing.T) {
+	ra := func(name string) *templates.RESTAction {
+		return &templates.RESTAction{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "demo"},
+		}
+	}
+
+	ctx, err := enterReferenceChain(context.Background(), ra("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err = enterReferenceChain(ctx, ra("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = enterReferenceChain(ctx, ra("a"))
+	if !errors.Is(err, errReferenceLoop) {
+		t.Fatalf("expected reference loop, got: %v", err)
+	}
+	if want := "restaction reference loop: demo/a -> demo/b -> demo/a"; err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+
+	ctx = context.Background()
+	for i := 0; i < maxReferenceDepth; i++ {
+		ctx, err = enterReferenceChain(ctx, ra(fmt.Sprintf("ra-%d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = enterReferenceChain(ctx, ra("too-deep"))
+	if !errors.Is(err, errReferenceLoop) {
+		t.Fatalf("expected maximum depth error, got: %v", err)
+	}
+}
@@ -10,7 +10,10 @@ import (
 	"github.com/krateoplatformops/plumbing/jqutil"
 	"github.com/krateoplatformops/plumbing/ptr"
 	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	crdschema "github.com/krateoplatformops/snowplow/internal/resolvers/crds/schema"
 	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/api"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/params"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/trace"
 	jqsupport "github.com/krateoplatformops/snowplow/internal/support/jq"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,20 +37,46 @@ type ResolveOptions struct {
 }
 
 func Resolve(ctx context.Context, opts ResolveOptions) (*templates.RESTAction, error) {
-	dict := api.Resolve(ctx, api.ResolveOptions{
-		RC:      opts.SArc,
-		AuthnNS: opts.AuthnNS,
-		Verbose: isVerbose(opts.In),
-		Items:   opts.In.Spec.API,
-		PerPage: opts.PerPage,
-		Page:    opts.Page,
-		Cursor:  opts.Cursor,
-		Extras:  opts.Extras,
+	ctx, err := enterReferenceChain(ctx, opts.In)
+	if err != nil {
+		return opts.In, err
+	}
+	ctx = trace.WithScope(ctx, fmt.Sprintf("%s/%s", opts.In.Namespace, opts.In.Name))
+
+	if to := opts.In.Spec.Timeout; to != nil && to.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, to.Duration)
+		defer cancel()
+	}
+
+	extras, err := params.Apply(opts.In.Spec.Params, opts.Extras)
+	if err != nil {
+		return opts.In, err
+	}
+
+	dict, outcomes, err := api.Resolve(ctx, api.ResolveOptions{
+		RC:        opts.SArc,
+		AuthnNS:   opts.AuthnNS,
+		Verbose:   isVerbose(opts.In),
+		Items:     opts.In.Spec.API,
+		PerPage:   opts.PerPage,
+		Page:      opts.Page,
+		Cursor:    opts.Cursor,
+		Extras:    extras,
+		Namespace: opts.In.Namespace,
+
+		RESTActionResolver: referenceResolver(opts),
 	})
+	if err != nil {
+		return opts.In, fmt.Errorf("unable to resolve api: %w", err)
+	}
 	if dict == nil {
 		dict = map[string]any{}
 	}
 
+	// a failure halted the resolution
+	failed := stepsError(outcomes)
+
 	log := xcontext.Logger(ctx)
 	log.Debug("resolved api", slog.Any("dict", dict))
 
@@ -59,6 +88,9 @@ func Resolve(ctx context.Context, opts ResolveOptions) (*templates.RESTAction, e
 			ModuleLoader: jqsupport.ModuleLoader(),
 		})
 		if err != nil {
+			if failed != nil {
+				return opts.In, failed
+			}
 			return opts.In, fmt.Errorf("unable to resolve filter: %w", err)
 		}
 
@@ -82,6 +114,20 @@ func Resolve(ctx context.Context, opts ResolveOptions) (*templates.RESTAction, e
 		opts.In.ManagedFields = nil
 	}
 
+	if failed != nil {
+		return opts.In, failed
+	}
+
+	if sch := opts.In.Spec.OutputSchema; sch != nil && len(sch.Raw) > 0 {
+		if err := crdschema.ValidateOutput(sch.Raw, raw, "status"); err != nil {
+			log.Warn("resolved status does not match the output schema",
+				slog.String("name", opts.In.Name),
+				slog.String("namespace", opts.In.Namespace),
+				slog.Any("err", err))
+			return opts.In, err
+		}
+	}
+
 	return opts.In, nil
 }
 
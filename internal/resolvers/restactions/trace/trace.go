@@ -0,0 +1,177 @@
+package trace
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = "<redacted>"
+
+// sensitiveHeaders are the (lowercase) header names, or fragments of them,
+// whose values are never exposed in the trace.
+var sensitiveHeaders = []string{
+	"authorization", "cookie", "token", "secret", "password", "api-key", "apikey",
+	"x-amz-security-token", "signature",
+}
+
+// Call describes a single request performed while resolving a RESTAction.
+type Call struct {
+	// API is the name of the API that performed the call.
+	API string `json:"api"`
+	// RESTAction is the namespace/name of the RESTAction declaring the API.
+	RESTAction string `json:"restAction,omitempty"`
+	// Kind is the kind of step (http, kubernetes, restActionRef).
+	Kind    string   `json:"kind"`
+	Verb    string   `json:"verb,omitempty"`
+	Path    string   `json:"path,omitempty"`
+	Headers []string `json:"headers,omitempty"`
+	Attempt int      `json:"attempt,omitempty"`
+	// Status is the response status code.
+	Status   int    `json:"status"`
+	Duration string `json:"duration"`
+	// Bytes is the size of the received response body.
+	Bytes int64 `json:"bytes"`
+	// Key is the dict key written with the result (or the error).
+	Key   string `json:"key,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Skip describes an API that has not been executed.
+type Skip struct {
+	API        string `json:"api"`
+	RESTAction string `json:"restAction,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// Recorder collects the execution plan and the performed calls;
+// it is safe for concurrent use. A nil Recorder records nothing.
+type Recorder struct {
+	mu      sync.Mutex
+	order   [][]string
+	calls   []Call
+	skipped []Skip
+}
+
+func New() *Recorder {
+	return &Recorder{}
+}
+
+// Trace is the JSON representation of the recorded data.
+type Trace struct {
+	// Order is the topological order of the APIs, grouped by level.
+	Order   [][]string `json:"order"`
+	Calls   []Call     `json:"calls"`
+	Skipped []Skip     `json:"skipped,omitempty"`
+}
+
+// SetOrder records the APIs resolution order; only the first
+// (the top level RESTAction one) is kept.
+func (r *Recorder) SetOrder(levels [][]string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.order == nil {
+		r.order = levels
+	}
+}
+
+func (r *Recorder) AddCall(call Call) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *Recorder) AddSkip(skip Skip) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.skipped = append(r.skipped, skip)
+}
+
+// Trace returns a snapshot of the recorded data.
+func (r *Recorder) Trace() Trace {
+	if r == nil {
+		return Trace{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := Trace{
+		Order:   r.order,
+		Calls:   append([]Call{}, r.calls...),
+		Skipped: append([]Skip{}, r.skipped...),
+	}
+	if res.Order == nil {
+		res.Order = [][]string{}
+	}
+	return res
+}
+
+type recorderKey struct{}
+
+type scopeKey struct{}
+
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// FromContext returns the recorder carried by the context (nil if tracing is disabled).
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// WithScope sets the namespace/name of the RESTAction being resolved.
+func WithScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+func Scope(ctx context.Context) string {
+	s, _ := ctx.Value(scopeKey{}).(string)
+	return s
+}
+
+// RedactHeaders returns a copy of the headers ("Name: value") hiding the sensitive values.
+func RedactHeaders(headers []string) []string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	res := make([]string, 0, len(headers))
+	for _, el := range headers {
+		name, _, ok := strings.Cut(el, ":")
+		if ok && isSensitive(name) {
+			el = strings.TrimSpace(name) + ": " + redacted
+		}
+		res = append(res, el)
+	}
+	return res
+}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, el := range sensitiveHeaders {
+		if strings.Contains(name, el) {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration formats the elapsed time since start.
+func Duration(start time.Time) string {
+	return time.Since(start).Round(time.Microsecond).String()
+}
@@ -0,0 +1,68 @@
+//go:build unit
+// +build unit
+
+package trace
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRedactHeaders(t *testing.T) {
+	got := RedactHeaders([]string{
+		"Accept: application/json",
+		"Authorization: Bearer abc",
+		"X-Api-Key: 123",
+		"x-auth-token:xyz",
+		"Cookie: session=1",
+	})
+
+	expect := []string{
+		"Accept: application/json",
+		"Authorization: <redacted>",
+		"X-Api-Key: <redacted>",
+		"x-auth-token: <redacted>",
+		"Cookie: <redacted>",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+
+	// tracing disabled
+	FromContext(ctx).AddCall(Call{API: "a"})
+	FromContext(ctx).SetOrder([][]string{{"a"}})
+
+	rec := New()
+	ctx = WithScope(WithRecorder(ctx, rec), "demo/ra")
+
+	FromContext(ctx).SetOrder([][]string{{"a", "b"}, {"c"}})
+	FromContext(ctx).SetOrder([][]string{{"nested"}})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			FromContext(ctx).AddCall(Call{API: "a", RESTAction: Scope(ctx)})
+		}()
+	}
+	wg.Wait()
+	FromContext(ctx).AddSkip(Skip{API: "b", Reason: "condition not satisfied"})
+
+	got := rec.Trace()
+	if !reflect.DeepEqual(got.Order, [][]string{{"a", "b"}, {"c"}}) {
+		t.Errorf("unexpected order: %v", got.Order)
+	}
+	if len(got.Calls) != 10 || got.Calls[0].RESTAction != "demo/ra" {
+		t.Errorf("unexpected calls: %v", got.Calls)
+	}
+	if len(got.Skipped) != 1 {
+		t.Errorf("unexpected skipped: %v", got.Skipped)
+	}
+}
@@ -0,0 +1,448 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/itchyny/gojq"
+	"github.com/krateoplatformops/plumbing/endpoints"
+	"github.com/krateoplatformops/plumbing/jqutil"
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	crdschema "github.com/krateoplatformops/snowplow/internal/resolvers/crds/schema"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/params"
+	jqsupport "github.com/krateoplatformops/snowplow/internal/support/jq"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+)
+
+// Severity of a diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a single problem found in a RESTAction.
+type Diagnostic struct {
+	// Path is the path of the field (e.g. spec.api[1].dependsOn[0].name).
+	Path     string   `json:"path"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+type Options struct {
+	// RC, holding the credentials of the user, is used to check that the
+	// referenced endpoint secrets exist; the check is skipped if nil.
+	RC *rest.Config
+}
+
+// Valid returns true if there are no error diagnostics.
+func Valid(all []Diagnostic) bool {
+	for _, el := range all {
+		if el.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate statically checks the RESTAction: the APIs dependency graph,
+// all the JQ expressions, the params, the output schema and the referenced
+// endpoint secrets.
+func Validate(ctx context.Context, in *templates.RESTAction, opts Options) []Diagnostic {
+	v := &validator{}
+
+	if in.Spec.Filter != nil {
+		v.checkJQ("spec.filter", ptr.Deref(in.Spec.Filter, ""))
+	}
+
+	if sch := in.Spec.OutputSchema; sch != nil && len(sch.Raw) > 0 {
+		if _, err := crdschema.CompileSchema(sch.Raw); err != nil {
+			v.errorf("spec.outputSchema", "invalid output schema: %v", err)
+		}
+	}
+
+	v.checkParams(in.Spec.Params)
+
+	names := v.checkNames(in.Spec.API)
+	v.checkGraph(in.Spec.API, names)
+
+	for i, el := range in.Spec.API {
+		if el == nil {
+			continue
+		}
+		v.checkAPI(fmt.Sprintf("spec.api[%d]", i), el)
+	}
+
+	if opts.RC != nil {
+		v.checkEndpoints(ctx, opts.RC, in.Spec.API)
+	}
+
+	return v.diagnostics
+}
+
+type validator struct {
+	diagnostics []Diagnostic
+}
+
+func (v *validator) errorf(path, format string, args ...any) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		Path: path, Severity: SeverityError, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) warnf(path, format string, args ...any) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		Path: path, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkParams checks the declared params and their defaults.
+func (v *validator) checkParams(items []templates.Param) {
+	seen := make(map[string]int, len(items))
+	for i, el := range items {
+		path := fmt.Sprintf("spec.params[%d]", i)
+		if len(el.Name) == 0 {
+			v.errorf(path+".name", "param name is required")
+			continue
+		}
+		if j, ok := seen[el.Name]; ok {
+			v.errorf(path+".name", "duplicate param name %q (already used by spec.params[%d])", el.Name, j)
+			continue
+		}
+		seen[el.Name] = i
+
+		if el.Pattern != nil {
+			if _, err := regexp.Compile(*el.Pattern); err != nil {
+				v.errorf(path+".pattern", "invalid pattern: %v", err)
+				continue
+			}
+		}
+
+		if _, err := params.Default(el); err != nil {
+			v.errorf(path+".default", "%v", err)
+		}
+	}
+}
+
+// checkNames checks that all APIs have a unique name; returns the names index.
+func (v *validator) checkNames(items []*templates.API) map[string]int {
+	names := make(map[string]int, len(items))
+	for i, el := range items {
+		path := fmt.Sprintf("spec.api[%d]", i)
+		if el == nil {
+			v.errorf(path, "api must not be null")
+			continue
+		}
+
+		if len(el.Name) == 0 {
+			v.errorf(path+".name", "api name is required")
+			continue
+		}
+
+		if j, ok := names[el.Name]; ok {
+			v.errorf(path+".name", "duplicate api name %q (already used by spec.api[%d])", el.Name, j)
+			continue
+		}
+		names[el.Name] = i
+	}
+
+	return names
+}
+
+// checkGraph checks the dependencies between the APIs.
+func (v *validator) checkGraph(items []*templates.API, names map[string]int) {
+	graph := make(map[string][]string, len(items))
+
+	for i, el := range items {
+		if el == nil || len(el.Name) == 0 {
+			continue
+		}
+
+		if el.DependsOn == nil {
+			continue
+		}
+
+		path := fmt.Sprintf("spec.api[%d].dependsOn", i)
+		type dependency struct{ path, name string }
+
+		var deps []dependency
+		if len(el.DependsOn.Name) > 0 {
+			deps = append(deps, dependency{path + ".name", el.DependsOn.Name})
+		}
+		for j, dep := range el.DependsOn.Names {
+			deps = append(deps, dependency{fmt.Sprintf("%s.names[%d]", path, j), dep})
+		}
+		if len(deps) == 0 {
+			v.errorf(path, "dependency name or names is required")
+		}
+		if len(iteratorOf(el)) > 0 && len(el.DependsOn.Name) == 0 {
+			v.errorf(path+".iterator", "iterator requires the dependency name")
+		}
+
+		for _, dep := range deps {
+			if dep.name == el.Name {
+				v.errorf(dep.path, "api %q depends on itself", el.Name)
+				continue
+			}
+
+			if _, ok := names[dep.name]; !ok {
+				v.errorf(dep.path, "api %q depends on unknown api %q", el.Name, dep.name)
+				continue
+			}
+			graph[el.Name] = append(graph[el.Name], dep.name)
+		}
+	}
+
+	for _, cycle := range findCycles(graph) {
+		v.errorf(fmt.Sprintf("spec.api[%d].dependsOn", names[cycle[0]]),
+			"cyclic dependency detected (%s)", strings.Join(cycle, " -> "))
+	}
+}
+
+// iteratorOf returns the iterator declared by the API dependency (if any).
+func iteratorOf(in *templates.API) string {
+	if in.DependsOn == nil {
+		return ""
+	}
+	return ptr.Deref(in.DependsOn.Iterator, "")
+}
+
+// findCycles returns the dependency cycles, each one starting and ending with the same API.
+func findCycles(graph map[string][]string) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	keys := make([]string, 0, len(graph))
+	for k := range graph {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	state := map[string]int{}
+	stack := []string{}
+	all := [][]string{}
+
+	var visit func(string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+
+		for _, dep := range graph[name] {
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				idx := 0
+				for i, el := range stack {
+					if el == dep {
+						idx = i
+						break
+					}
+				}
+				cycle := append([]string{}, stack[idx:]...)
+				all = append(all, append(cycle, dep))
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+	}
+
+	for _, k := range keys {
+		if state[k] == unvisited {
+			visit(k)
+		}
+	}
+
+	return all
+}
+
+// checkAPI compiles all the JQ expressions of the API.
+func (v *validator) checkAPI(path string, in *templates.API) {
+	steps := 0
+	if in.RESTActionRef != nil {
+		steps++
+	}
+	if in.Kubernetes != nil {
+		steps++
+	}
+	if steps > 1 {
+		v.errorf(path, "restActionRef and kubernetes are mutually exclusive")
+	}
+	if steps > 0 && (in.EndpointRef != nil || len(in.Path) > 0) {
+		v.warnf(path, "endpointRef and path are ignored by restActionRef and kubernetes steps")
+	}
+	if steps > 0 && len(in.Env) > 0 {
+		v.warnf(path+".env", "env is ignored by restActionRef and kubernetes steps")
+	}
+
+	for i, el := range in.Env {
+		p := fmt.Sprintf("%s.env[%d]", path, i)
+		if len(el.Name) == 0 {
+			v.errorf(p+".name", "env name is required")
+		}
+		src := el.ValueFrom
+		if (src.SecretKeyRef == nil) == (src.ConfigMapKeyRef == nil) {
+			v.errorf(p+".valueFrom", "exactly one of secretKeyRef or configMapKeyRef is required")
+		}
+	}
+
+	// the iterated expressions see the element as $item and $index
+	var vars []string
+	if len(iteratorOf(in)) > 0 {
+		vars = []string{"$" + jqsupport.VarItem, "$" + jqsupport.VarIndex}
+	}
+
+	v.checkTemplate(path+".path", in.Path, vars...)
+	if in.Payload != nil {
+		v.checkTemplate(path+".payload", *in.Payload, vars...)
+	}
+	for i, el := range in.Headers {
+		v.checkTemplate(fmt.Sprintf("%s.headers[%d]", path, i), el, vars...)
+	}
+
+	if in.Filter != nil {
+		v.checkJQ(path+".filter", ptr.Deref(in.Filter, ""))
+	}
+	if in.When != nil {
+		q, _ := jqutil.MaybeQuery(ptr.Deref(in.When, ""))
+		v.checkJQ(path+".when", q)
+	}
+
+	if it := iteratorOf(in); len(it) > 0 {
+		v.checkJQ(path+".dependsOn.iterator", it)
+	}
+
+	if mp := in.Merge; mp != nil {
+		if mp.Strategy == templates.MergeKeyed && len(ptr.Deref(mp.Key, "")) == 0 {
+			v.errorf(path+".merge.key", "keyed merge requires the key expression")
+		}
+		if mp.Key != nil {
+			if mp.Strategy != templates.MergeKeyed {
+				v.warnf(path+".merge.key", "key is ignored by the %s merge strategy", mp.Strategy)
+			} else {
+				v.checkJQ(path+".merge.key", ptr.Deref(mp.Key, ""))
+			}
+		}
+	}
+
+	if in.ErrorFilter != nil {
+		v.checkJQ(path+".errorFilter", ptr.Deref(in.ErrorFilter, ""))
+	}
+	if in.Fallback != nil && len(in.Fallback.Raw) > 0 {
+		var val any
+		if err := json.Unmarshal(in.Fallback.Raw, &val); err != nil {
+			v.errorf(path+".fallback", "invalid fallback: %s", err.Error())
+		} else if s, ok := val.(string); ok {
+			v.checkTemplate(path+".fallback", s)
+		}
+	}
+
+	if pag := in.Paginate; pag != nil {
+		if pag.Items != nil {
+			v.checkJQ(path+".paginate.items", ptr.Deref(pag.Items, ""))
+		}
+		if pag.Next != nil {
+			v.checkJQ(path+".paginate.next", ptr.Deref(pag.Next, ""))
+		}
+		if pag.Type == templates.PaginationCursor {
+			if pag.Next == nil {
+				v.errorf(path+".paginate.next", "cursor pagination requires the next expression")
+			}
+			if len(ptr.Deref(pag.Param, "")) == 0 {
+				v.errorf(path+".paginate.param", "cursor pagination requires the param name")
+			}
+		}
+	}
+
+	if ref := in.RESTActionRef; ref != nil {
+		if len(ref.Name) == 0 || len(ref.Namespace) == 0 {
+			v.errorf(path+".restActionRef", "name and namespace are required")
+		}
+		for i, el := range ref.Extras {
+			v.checkTemplate(fmt.Sprintf("%s.restActionRef.extras[%d].value", path, i), el.Value)
+		}
+	}
+
+	if k := in.Kubernetes; k != nil {
+		fields := []struct{ name, value string }{
+			{"apiVersion", k.APIVersion}, {"resource", k.Resource},
+			{"namespace", k.Namespace}, {"name", k.Name},
+			{"labelSelector", k.LabelSelector}, {"fieldSelector", k.FieldSelector},
+		}
+		for _, el := range fields {
+			v.checkTemplate(path+".kubernetes."+el.name, el.value, vars...)
+		}
+		if len(k.APIVersion) == 0 || len(k.Resource) == 0 {
+			v.errorf(path+".kubernetes", "apiVersion and resource are required")
+		}
+	}
+}
+
+// checkTemplate compiles the JQ expression if the value is wrapped in `${ }`.
+func (v *validator) checkTemplate(path, s string, vars ...string) {
+	if q, ok := jqutil.MaybeQuery(s); ok {
+		v.checkJQ(path, q, vars...)
+	}
+}
+
+func (v *validator) checkJQ(path, q string, vars ...string) {
+	if err := compileJQ(q, vars...); err != nil {
+		v.errorf(path, "%s", err.Error())
+	}
+}
+
+// compileJQ compiles the query with the given variables (i.e. $item) defined.
+func compileJQ(q string, vars ...string) error {
+	query, err := gojq.Parse(q)
+	if err != nil {
+		return fmt.Errorf("invalid jq query %q: %w", q, err)
+	}
+
+	opts := []gojq.CompilerOption{}
+	if loader := jqsupport.ModuleLoader(); loader != nil {
+		opts = append(opts, gojq.WithModuleLoader(loader))
+	}
+	if len(vars) > 0 {
+		opts = append(opts, gojq.WithVariables(vars))
+	}
+
+	if _, err := gojq.Compile(query, opts...); err != nil {
+		return fmt.Errorf("unable to compile jq query %q: %w", q, err)
+	}
+
+	return nil
+}
+
+// checkEndpoints verifies that the referenced endpoint secrets exist.
+func (v *validator) checkEndpoints(ctx context.Context, rc *rest.Config, items []*templates.API) {
+	for i, el := range items {
+		if el == nil || el.EndpointRef == nil {
+			continue
+		}
+
+		path := fmt.Sprintf("spec.api[%d].endpointRef", i)
+		ref := el.EndpointRef
+		if len(ref.Name) == 0 || len(ref.Namespace) == 0 {
+			v.errorf(path, "name and namespace are required")
+			continue
+		}
+
+		_, err := endpoints.FromSecret(ctx, rc, ref.Name, ref.Namespace)
+		if apierrors.IsForbidden(err) {
+			v.warnf(path, "endpoint secret %s/%s not checked: not allowed to read it", ref.Namespace, ref.Name)
+		} else if err != nil {
+			v.errorf(path, "unable to get endpoint secret %s/%s: %s", ref.Namespace, ref.Name, err.Error())
+		}
+	}
+}
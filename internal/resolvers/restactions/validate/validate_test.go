@@ -0,0 +1,304 @@
+//go:build unit
+// +build unit
+
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/ptr"
+	templates "github.com/krateoplatformops/snowplow/apis/templates/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   string
+		expect []Diagnostic
+	}{
+		{
+			name: "valid",
+			spec: `
+filter: .users | map(.name)
+api:
+- name: users
+  path: /users
+  filter: .users.items
+- name: roles
+  path: ${ "/users/" + $item.id + "/roles" }
+  headers: ["${ \"X-Index: \" + ($index|tostring) }"]
+  dependsOn:
+    name: users
+    iterator: .users
+`,
+		},
+		{
+			name: "iteration variables",
+			spec: `
+api:
+- name: users
+  path: ${ "/users/" + $item.id }
+- name: roles
+  path: ${ "/roles/" + $item.id }
+  dependsOn:
+    name: users
+  filter: $index
+`,
+			expect: []Diagnostic{
+				{Path: "spec.api[0].path", Severity: SeverityError},
+				{Path: "spec.api[1].path", Severity: SeverityError},
+				{Path: "spec.api[1].filter", Severity: SeverityError},
+			},
+		},
+		{
+			name: "dependencies",
+			spec: `
+api:
+- name: a
+  dependsOn: {name: c}
+- name: b
+  dependsOn: {names: [a, missing]}
+- name: c
+  dependsOn: {name: b}
+- name: a
+- name: d
+  dependsOn: {names: [a], iterator: .a}
+`,
+			expect: []Diagnostic{
+				{Path: "spec.api[3].name", Severity: SeverityError, Message: `duplicate api name "a" (already used by spec.api[0])`},
+				{Path: "spec.api[1].dependsOn.names[1]", Severity: SeverityError, Message: `api "b" depends on unknown api "missing"`},
+				{Path: "spec.api[4].dependsOn.iterator", Severity: SeverityError, Message: `iterator requires the dependency name`},
+				{Path: "spec.api[0].dependsOn", Severity: SeverityError, Message: `cyclic dependency detected (a -> c -> b -> a)`},
+			},
+		},
+		{
+			name: "jq expressions",
+			spec: `
+filter: .users[
+api:
+- name: users
+  path: ${ .id | }
+  headers: ["X-Id: ${ .id }", "X-Bad: ${ .id + }"]
+  when: ${ .enabled and }
+  paginate:
+    type: cursor
+    items: .items
+`,
+			expect: []Diagnostic{
+				{Path: "spec.filter", Severity: SeverityError},
+				{Path: "spec.api[0].path", Severity: SeverityError},
+				{Path: "spec.api[0].headers[1]", Severity: SeverityError},
+				{Path: "spec.api[0].when", Severity: SeverityError},
+				{Path: "spec.api[0].paginate.next", Severity: SeverityError, Message: "cursor pagination requires the next expression"},
+				{Path: "spec.api[0].paginate.param", Severity: SeverityError, Message: "cursor pagination requires the param name"},
+			},
+		},
+		{
+			name: "env",
+			spec: `
+api:
+- name: users
+  path: /users
+  headers: ["X-Api-Key: $(API_KEY)"]
+  env:
+  - name: API_KEY
+    valueFrom:
+      secretKeyRef: {name: tenant, key: token}
+  - name: ""
+    valueFrom:
+      secretKeyRef: {name: tenant, key: token}
+      configMapKeyRef: {name: tenant, key: url}
+`,
+			expect: []Diagnostic{
+				{Path: "spec.api[0].env[1].name", Severity: SeverityError},
+				{Path: "spec.api[0].env[1].valueFrom", Severity: SeverityError},
+			},
+		},
+		{
+			name: "params",
+			spec: `
+params:
+- name: size
+  type: integer
+  default: 10
+- name: order
+  enum: [asc, desc]
+  default: random
+- name: ""
+- name: size
+- name: tag
+  pattern: "(["
+`,
+			expect: []Diagnostic{
+				{Path: "spec.params[1].default", Severity: SeverityError},
+				{Path: "spec.params[2].name", Severity: SeverityError},
+				{Path: "spec.params[3].name", Severity: SeverityError},
+				{Path: "spec.params[4].pattern", Severity: SeverityError},
+			},
+		},
+		{
+			name: "merge",
+			spec: `
+api:
+- name: users
+  path: /users
+  merge: {strategy: keyed}
+- name: roles
+  path: /roles
+  merge: {strategy: concat, key: .id}
+- name: groups
+  path: /groups
+  merge: {strategy: keyed, key: .id | }
+`,
+			expect: []Diagnostic{
+				{Path: "spec.api[0].merge.key", Severity: SeverityError},
+				{Path: "spec.api[1].merge.key", Severity: SeverityWarning},
+				{Path: "spec.api[2].merge.key", Severity: SeverityError},
+			},
+		},
+		{
+			name: "fallback",
+			spec: `
+api:
+- name: users
+  path: /users
+  fallback: {items: []}
+  errorFilter: "{code, message}"
+- name: roles
+  path: /roles
+  fallback: ${ .extras.roles | }
+  errorFilter: "{code"
+`,
+			expect: []Diagnostic{
+				{Path: "spec.api[1].errorFilter", Severity: SeverityError},
+				{Path: "spec.api[1].fallback", Severity: SeverityError},
+			},
+		},
+		{
+			name: "output schema",
+			spec: `
+outputSchema:
+  type: object
+  properties: name
+`,
+			expect: []Diagnostic{
+				{Path: "spec.outputSchema", Severity: SeverityError},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := &templates.RESTAction{}
+			if err := yaml.Unmarshal([]byte(tc.spec), &in.Spec); err != nil {
+				t.Fatal(err)
+			}
+
+			got := Validate(context.Background(), in, Options{})
+			if len(got) != len(tc.expect) {
+				t.Fatalf("expected %d diagnostics, got %d: %v", len(tc.expect), len(got), got)
+			}
+
+			for i, want := range tc.expect {
+				if got[i].Path != want.Path || got[i].Severity != want.Severity {
+					t.Errorf("[%d] expected %s (%s), got %s (%s)", i,
+						want.Path, want.Severity, got[i].Path, got[i].Severity)
+				}
+				if len(want.Message) > 0 && got[i].Message != want.Message {
+					t.Errorf("[%d] expected message %q, got %q", i, want.Message, got[i].Message)
+				}
+			}
+
+			if Valid(got) != (len(tc.expect) == 0) {
+				t.Errorf("unexpected validity for %v", got)
+			}
+		})
+	}
+}
+
+func TestValidateSteps(t *testing.T) {
+	in := &templates.RESTAction{
+		Spec: templates.RESTActionSpec{
+			API: []*templates.API{
+				{
+					Name: "pods",
+					Path: "/api/v1/pods",
+					Kubernetes: &templates.KubernetesResource{
+						APIVersion: "v1", Resource: "pods", Namespace: "${ .ns[ }",
+					},
+				},
+				{
+					Name:          "nested",
+					RESTActionRef: &templates.RESTActionReference{Reference: templates.Reference{Name: "other"}},
+					Filter:        ptr.To(".nested"),
+				},
+			},
+		},
+	}
+
+	got := Validate(context.Background(), in, Options{})
+
+	paths := []string{}
+	for _, el := range got {
+		paths = append(paths, el.Path+":"+string(el.Severity))
+	}
+
+	expect := []string{
+		"spec.api[0]:warning",
+		"spec.api[0].kubernetes.namespace:error",
+		"spec.api[1].restActionRef:error",
+	}
+	if len(paths) != len(expect) {
+		t.Fatalf("expected %v, got %v", expect, paths)
+	}
+	for i := range expect {
+		if paths[i] != expect[i] {
+			t.Errorf("expected %v, got %v", expect, paths)
+		}
+	}
+}
+
+func TestValidateEndpoints(t *testing.T) {
+	// the API server as seen with the user credentials
+	srv := httptest.NewServer(http.HandlerFunc(func(wri http.ResponseWriter, req *http.Request) {
+		wri.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/api/v1/namespaces/demo/secrets/helpdesk":
+			fmt.Fprint(wri, `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"helpdesk","namespace":"demo"},"data":{"server-url":"aHR0cHM6Ly9leGFtcGxlLmNvbQ=="}}`)
+		case "/api/v1/namespaces/secret/secrets/admin":
+			wri.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(wri, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403,"message":"secrets is forbidden"}`)
+		default:
+			wri.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(wri, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404,"message":"secrets not found"}`)
+		}
+	}))
+	defer srv.Close()
+
+	in := &templates.RESTAction{}
+	in.Spec.API = []*templates.API{
+		{Name: "ok", Path: "/", EndpointRef: &templates.Reference{Name: "helpdesk", Namespace: "demo"}},
+		{Name: "forbidden", Path: "/", EndpointRef: &templates.Reference{Name: "admin", Namespace: "secret"}},
+		{Name: "missing", Path: "/", EndpointRef: &templates.Reference{Name: "nope", Namespace: "demo"}},
+	}
+
+	got := Validate(context.Background(), in, Options{RC: &rest.Config{Host: srv.URL}})
+	if len(got) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %+v", got)
+	}
+	// the secrets the user cannot read are not checked
+	if got[0].Path != "spec.api[1].endpointRef" || got[0].Severity != SeverityWarning ||
+		strings.Contains(got[0].Message, "forbidden") {
+		t.Errorf("unexpected diagnostic: %+v", got[0])
+	}
+	if got[1].Path != "spec.api[2].endpointRef" || got[1].Severity != SeverityError {
+		t.Errorf("unexpected diagnostic: %+v", got[1])
+	}
+}
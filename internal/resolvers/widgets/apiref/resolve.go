@@ -2,6 +2,7 @@ package apiref
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	templatesv1 "github.com/krateoplatformops/snowplow/apis/templates/v1"
@@ -31,7 +32,7 @@ func Resolve(ctx context.Context, opts ResolveOptions) (map[string]any, error) {
 	}
 
 	ra, err := convertToRESTAction(res.Unstructured.Object)
-	if res.Err != nil {
+	if err != nil {
 		return map[string]any{}, err
 	}
 
@@ -45,9 +46,19 @@ func Resolve(ctx context.Context, opts ResolveOptions) (map[string]any, error) {
 		Extras:  opts.Extras,
 	}
 
-	if _, err = restactions.Resolve(ctx, raopts); err != nil {
-		return map[string]any{}, err
+	_, err = restactions.Resolve(ctx, raopts)
+	if err != nil {
+		// an API failure still returns the partial results
+		var stepsErr *restactions.StepsError
+		if !errors.As(err, &stepsErr) {
+			return map[string]any{}, err
+		}
+	}
+
+	ds, cerr := rawExtensionToMap(ra.Status)
+	if cerr != nil {
+		return ds, cerr
 	}
 
-	return rawExtensionToMap(ra.Status)
+	return ds, err
 }
@@ -2,6 +2,7 @@ package widgets
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"reflect"
@@ -11,6 +12,7 @@ import (
 	"github.com/krateoplatformops/plumbing/maps"
 	v1 "github.com/krateoplatformops/snowplow/apis/templates/v1"
 	crdschema "github.com/krateoplatformops/snowplow/internal/resolvers/crds/schema"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions"
 
 	"github.com/krateoplatformops/snowplow/internal/resolvers/widgets/apiref"
 	"github.com/krateoplatformops/snowplow/internal/resolvers/widgets/resourcesrefs"
@@ -39,7 +41,12 @@ func Resolve(ctx context.Context, opts ResolveOptions) (*Widget, error) {
 	log := xcontext.Logger(ctx).With(loggerAttr(opts.In.Object))
 
 	ds, err := resolveApiRef(ctx, opts)
-	if err != nil {
+	// an API failure does not stop the widget resolution:
+	// the partial data is used and the failure is reported
+	var stepsErr *restactions.StepsError
+	if errors.As(err, &stepsErr) {
+		log.Warn("api reference partially resolved", slog.Any("err", err))
+	} else if err != nil {
 		log.Error("unable to resolve api reference", slog.Any("err", err))
 		maps.SetNestedField(opts.In.Object, err.Error(), "status", "error")
 		return opts.In, err
@@ -122,6 +129,11 @@ func Resolve(ctx context.Context, opts ResolveOptions) (*Widget, error) {
 			}}
 	}
 
+	if stepsErr != nil {
+		maps.SetNestedField(opts.In.Object, stepsErr.Error(), "status", "error")
+		return opts.In, stepsErr
+	}
+
 	return opts.In, nil
 }
 
@@ -9,7 +9,6 @@ import (
 	"strconv"
 
 	xcontext "github.com/krateoplatformops/plumbing/context"
-	"github.com/krateoplatformops/plumbing/kubeconfig"
 	templatesv1 "github.com/krateoplatformops/snowplow/apis/templates/v1"
 	"github.com/krateoplatformops/snowplow/internal/dynamic"
 	"github.com/krateoplatformops/snowplow/internal/rbac"
@@ -23,7 +22,7 @@ func Resolve(ctx context.Context, items []templatesv1.ResourceRef) ([]templatesv
 		return nil, err
 	}
 
-	rc, err := kubeconfig.NewClientConfig(ctx, ep)
+	rc, err := dynamic.ClientConfig(ctx, ep)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +55,7 @@ func resolveOne(ctx context.Context, rc *rest.Config, in *templatesv1.ResourceRe
 	}
 	gvr := gv.WithResource(in.Resource)
 
-	gvk, err := dynamic.KindFor(rc, gvr)
+	gvk, err := dynamic.KindForContext(ctx, rc, gvr)
 	if err != nil {
 		return all, err
 	}
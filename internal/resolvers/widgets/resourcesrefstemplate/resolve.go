@@ -50,8 +50,9 @@ func createResourceReferencesFromTemplate(ctx context.Context, in *templatesv1.R
 
 	all = []templatesv1.ResourceRef{}
 
+	// the iterated element is bound as $item (and its position as $index)
 	action := func(sa any) error {
-		el := createResourceRef(in, sa)
+		el := createResourceRef(in, ds, jqsupport.IterationVars(sa, len(all))...)
 		all = append(all, el)
 		return nil
 	}
@@ -67,29 +68,34 @@ func createResourceReferencesFromTemplate(ctx context.Context, in *templatesv1.R
 	return
 }
 
-func createResourceRef(in *templatesv1.ResourceRefTemplate, ds any) (out templatesv1.ResourceRef) {
-	out.ID = evalJQ(in.Template.ID, ds)
+func createResourceRef(in *templatesv1.ResourceRefTemplate, ds any, vars ...jqsupport.Var) (out templatesv1.ResourceRef) {
+	out.ID = evalJQ(in.Template.ID, ds, vars)
 	out.Verb = in.Template.Verb
 
-	out.APIVersion = evalJQ(in.Template.APIVersion, ds)
-	out.Name = evalJQ(in.Template.Name, ds)
-	out.Namespace = evalJQ(in.Template.Namespace, ds)
-	out.Resource = evalJQ(in.Template.Resource, ds)
+	out.APIVersion = evalJQ(in.Template.APIVersion, ds, vars)
+	out.Name = evalJQ(in.Template.Name, ds, vars)
+	out.Namespace = evalJQ(in.Template.Namespace, ds, vars)
+	out.Resource = evalJQ(in.Template.Resource, ds, vars)
 
 	return
 }
 
-func evalJQ(q string, ds any) string {
+func evalJQ(q string, ds any, vars []jqsupport.Var) string {
 	q, ok := jqutil.MaybeQuery(q)
 	if !ok {
 		return q
 	}
 
+	q, data, err := jqsupport.Bind(q, ds, vars...)
+	if err != nil {
+		return err.Error()
+	}
+
 	out, err := jqutil.Eval(context.TODO(),
 		jqutil.EvalOptions{
 			Query:        q,
 			Unquote:      true,
-			Data:         ds,
+			Data:         data,
 			ModuleLoader: jqsupport.ModuleLoader(),
 		})
 	if err != nil {
@@ -0,0 +1,137 @@
+//go:build unit
+// +build unit
+
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAzureStringToSign(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPut,
+		"https://myaccount.blob.core.windows.net/mycontainer/myblob?restype=container&comp=metadata", nil)
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("x-ms-date", "Fri, 26 Jun 2015 23:39:12 GMT")
+	req.Header.Set("x-ms-version", "2015-02-21")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+
+	want := strings.Join([]string{
+		"PUT", "", "", "11", "", "text/plain", "", "", "", "", "", "",
+		"x-ms-blob-type:BlockBlob",
+		"x-ms-date:Fri, 26 Jun 2015 23:39:12 GMT",
+		"x-ms-version:2015-02-21",
+		"/myaccount/mycontainer/myblob",
+		"comp:metadata",
+		"restype:container",
+	}, "\n")
+
+	if got := AzureStringToSign(req, "myaccount", 11); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestAzureCanonicalResource(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "mixed case names",
+			url:  "https://myaccount.blob.core.windows.net/mycontainer?Comp=list&restype=container",
+			want: "/myaccount/mycontainer\ncomp:list\nrestype:container",
+		},
+		{
+			name: "mixed case names sorted once lowercased",
+			url:  "https://myaccount.blob.core.windows.net/mycontainer?Timeout=30&Comp=list&restype=container",
+			want: "/myaccount/mycontainer\ncomp:list\nrestype:container\ntimeout:30",
+		},
+		{
+			name: "names differing only in case",
+			url:  "https://myaccount.blob.core.windows.net/mycontainer?restype=container&Include=snapshots&include=metadata",
+			want: "/myaccount/mycontainer\ninclude:metadata,snapshots\nrestype:container",
+		},
+		{
+			name: "no path",
+			url:  "https://myaccount.blob.core.windows.net?Comp=list",
+			want: "/myaccount/\ncomp:list",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := azureCanonicalResource(u, "myaccount"); got != tc.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAzureSigner(t *testing.T) {
+	const account = "devstoreaccount1"
+	key := base64.StdEncoding.EncodeToString([]byte("storage-account-key"))
+
+	// stand-in storage service verifying the Shared Key authorization
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mac := hmac.New(sha256.New, []byte("storage-account-key"))
+		mac.Write([]byte(AzureStringToSign(r, account, int64(len(body)))))
+		want := "SharedKey " + account + ":" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+		if r.Header.Get("Authorization") != want || r.Header.Get("x-ms-version") != defaultAzureVersion {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	s, err := New(Config{
+		SchemeLabel:       SchemeAzureSharedKey,
+		AzureAccountLabel: account,
+		AzureKeyLabel:     key,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.(*azureSigner).now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	body := "hello"
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/container/blob.txt?comp=block&blockid=AAA", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	if err := s.Sign(req, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := req.Header.Get("x-ms-date"); got != "Thu, 02 Jan 2025 03:04:05 GMT" {
+		t.Errorf("unexpected x-ms-date: %s", got)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("signature rejected: %d", res.StatusCode)
+	}
+
+	if _, err := New(Config{SchemeLabel: SchemeAzureSharedKey, AzureAccountLabel: account, AzureKeyLabel: "%%%"}); err == nil {
+		t.Errorf("expected invalid key error")
+	}
+}
@@ -0,0 +1,59 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// SchemeHMAC signs the request body with HMAC-SHA256 (i.e. webhooks).
+const SchemeHMAC = "hmac-sha256"
+
+// HMAC scheme keys.
+const (
+	HMACSecretLabel = "signing-secret"
+	HMACHeaderLabel = "signing-header"
+	HMACPrefixLabel = "signing-prefix"
+)
+
+const (
+	defaultHMACHeader = "X-Signature-256"
+	defaultHMACPrefix = "sha256="
+)
+
+func init() {
+	Register(SchemeHMAC, newHMACSigner)
+}
+
+type hmacSigner struct {
+	secret []byte
+	header string
+	prefix string
+}
+
+func newHMACSigner(cfg Config) (Signer, error) {
+	secret, err := cfg.Required(HMACSecretLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &hmacSigner{
+		secret: []byte(secret),
+		header: cfg.Get(HMACHeaderLabel, defaultHMACHeader),
+		prefix: cfg.Get(HMACPrefixLabel, defaultHMACPrefix),
+	}, nil
+}
+
+// Sign sets the header to the prefixed, hex encoded, body signature.
+func (s *hmacSigner) Sign(req *http.Request, body []byte) error {
+	req.Header.Set(s.header, s.prefix+HMACSignature(s.secret, body))
+	return nil
+}
+
+// HMACSignature returns the hex encoded HMAC-SHA256 of the body.
+func HMACSignature(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
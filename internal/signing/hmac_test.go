@@ -0,0 +1,74 @@
+//go:build unit
+// +build unit
+
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHMACSigner(t *testing.T) {
+	const secret = "webhook-secret"
+
+	// stand-in webhook receiver verifying the body signature
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+		if !hmac.Equal([]byte(r.Header.Get("X-Hub-Signature-256")), []byte(want)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s, err := New(Config{
+		SchemeLabel:     SchemeHMAC,
+		HMACSecretLabel: secret,
+		HMACHeaderLabel: "X-Hub-Signature-256",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, body := range []string{`{"event": "push"}`, ""} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/hook", strings.NewReader(body))
+		if err := s.Sign(req, []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusNoContent {
+			t.Errorf("signature rejected for body %q: %d", body, res.StatusCode)
+		}
+	}
+
+	// a tampered body is rejected
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/hook", bytes.NewReader([]byte(`{"event": "tag"}`)))
+	s.Sign(req, []byte(`{"event": "push"}`))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected tampered body to be rejected, got %d", res.StatusCode)
+	}
+}
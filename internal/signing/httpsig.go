@@ -0,0 +1,274 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemeHTTPSig signs the request with HTTP Message Signatures (RFC 9421).
+const SchemeHTTPSig = "http-message-signatures"
+
+// HTTP Message Signatures scheme keys.
+const (
+	HTTPSigKeyIDLabel      = "signing-key-id"
+	HTTPSigAlgorithmLabel  = "signing-algorithm"
+	HTTPSigPrivateKeyLabel = "signing-private-key"
+	HTTPSigComponentsLabel = "signing-components"
+	HTTPSigLabelLabel      = "signing-label"
+)
+
+// HTTP Message Signatures algorithms.
+const (
+	AlgHMACSHA256      = "hmac-sha256"
+	AlgEd25519         = "ed25519"
+	AlgRSAPSSSHA512    = "rsa-pss-sha512"
+	AlgRSAv15SHA256    = "rsa-v1_5-sha256"
+	AlgECDSAP256SHA256 = "ecdsa-p256-sha256"
+)
+
+const (
+	defaultHTTPSigLabel = "sig1"
+	contentDigest       = "content-digest"
+)
+
+// defaultHTTPSigComponents are the covered components if not specified;
+// content-digest is covered only when the request has a body.
+var defaultHTTPSigComponents = []string{"@method", "@target-uri", contentDigest}
+
+func init() {
+	Register(SchemeHTTPSig, newHTTPSigSigner)
+}
+
+type httpSigSigner struct {
+	keyID      string
+	alg        string
+	label      string
+	components []string
+	defaults   bool
+	sign       func(base []byte) ([]byte, error)
+	now        func() time.Time
+}
+
+func newHTTPSigSigner(cfg Config) (Signer, error) {
+	keyID, err := cfg.Required(HTTPSigKeyIDLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &httpSigSigner{
+		keyID: keyID,
+		alg:   cfg.Get(HTTPSigAlgorithmLabel, AlgHMACSHA256),
+		label: cfg.Get(HTTPSigLabelLabel, defaultHTTPSigLabel),
+		now:   time.Now,
+	}
+
+	res.components = strings.FieldsFunc(strings.ToLower(cfg.Get(HTTPSigComponentsLabel, "")),
+		func(r rune) bool { return r == ',' || r == ' ' || r == '\n' })
+	if len(res.components) == 0 {
+		res.components, res.defaults = defaultHTTPSigComponents, true
+	}
+
+	// the hmac-sha256 algorithm uses the HMACSecretLabel shared secret
+	if res.alg == AlgHMACSHA256 {
+		secret, err := cfg.Required(HMACSecretLabel)
+		if err != nil {
+			return nil, err
+		}
+		res.sign = func(base []byte) ([]byte, error) {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(base)
+			return mac.Sum(nil), nil
+		}
+		return res, nil
+	}
+
+	dat, err := cfg.Required(HTTPSigPrivateKeyLabel)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parsePrivateKey([]byte(dat))
+	if err != nil {
+		return nil, err
+	}
+
+	res.sign, err = keySigner(res.alg, key)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Sign sets the Content-Digest (if covered), Signature-Input and Signature headers.
+func (s *httpSigSigner) Sign(req *http.Request, body []byte) error {
+	components := s.components
+	if s.defaults && len(body) == 0 {
+		components = components[:len(components)-1]
+	}
+
+	for _, el := range components {
+		if el == contentDigest {
+			sum := sha256.Sum256(body)
+			req.Header.Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+			break
+		}
+	}
+
+	params := SignatureParams(components, s.now().Unix(), s.keyID, s.alg)
+	base, err := SignatureBase(req, components, params)
+	if err != nil {
+		return err
+	}
+
+	sig, err := s.sign([]byte(base))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature-Input", s.label+"="+params)
+	req.Header.Set("Signature", s.label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+// SignatureParams returns the serialized @signature-params component value.
+func SignatureParams(components []string, created int64, keyID, alg string) string {
+	quoted := make([]string, 0, len(components))
+	for _, el := range components {
+		quoted = append(quoted, strconv.Quote(el))
+	}
+
+	return fmt.Sprintf("(%s);created=%d;keyid=%s;alg=%s",
+		strings.Join(quoted, " "), created, strconv.Quote(keyID), strconv.Quote(alg))
+}
+
+// SignatureBase returns the signature base of the request (RFC 9421, section 2.5).
+func SignatureBase(req *http.Request, components []string, params string) (string, error) {
+	var sb strings.Builder
+	for _, el := range components {
+		val, err := componentValue(req, el)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "%q: %s\n", el, val)
+	}
+	fmt.Fprintf(&sb, "%q: %s", "@signature-params", params)
+	return sb.String(), nil
+}
+
+func componentValue(req *http.Request, name string) (string, error) {
+	u := req.URL
+	switch name {
+	case "@method":
+		return strings.ToUpper(req.Method), nil
+	case "@target-uri":
+		return u.String(), nil
+	case "@authority":
+		return strings.ToLower(u.Host), nil
+	case "@scheme":
+		return strings.ToLower(u.Scheme), nil
+	case "@request-target":
+		return u.RequestURI(), nil
+	case "@path":
+		if p := u.EscapedPath(); len(p) > 0 {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + u.RawQuery, nil
+	}
+
+	if strings.HasPrefix(name, "@") {
+		return "", fmt.Errorf("unsupported derived component %q", name)
+	}
+
+	vals := req.Header.Values(name)
+	if len(vals) == 0 {
+		return "", fmt.Errorf("covered header %q not found in request", name)
+	}
+	all := make([]string, 0, len(vals))
+	for _, el := range vals {
+		all = append(all, strings.TrimSpace(el))
+	}
+	return strings.Join(all, ", "), nil
+}
+
+func parsePrivateKey(dat []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(dat)
+	if block == nil {
+		return nil, fmt.Errorf("invalid %s: PEM block not found", HTTPSigPrivateKeyLabel)
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if el, ok := key.(crypto.Signer); ok {
+			return el, nil
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("invalid %s: unsupported key format", HTTPSigPrivateKeyLabel)
+}
+
+func keySigner(alg string, key crypto.Signer) (func([]byte) ([]byte, error), error) {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		if alg != AlgEd25519 {
+			break
+		}
+		return func(base []byte) ([]byte, error) {
+			return ed25519.Sign(k, base), nil
+		}, nil
+
+	case *rsa.PrivateKey:
+		switch alg {
+		case AlgRSAPSSSHA512:
+			return func(base []byte) ([]byte, error) {
+				sum := sha512.Sum512(base)
+				return rsa.SignPSS(rand.Reader, k, crypto.SHA512, sum[:],
+					&rsa.PSSOptions{SaltLength: 64})
+			}, nil
+		case AlgRSAv15SHA256:
+			return func(base []byte) ([]byte, error) {
+				sum := sha256.Sum256(base)
+				return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum[:])
+			}, nil
+		}
+
+	case *ecdsa.PrivateKey:
+		if alg != AlgECDSAP256SHA256 {
+			break
+		}
+		return func(base []byte) ([]byte, error) {
+			sum := sha256.Sum256(base)
+			r, s, err := ecdsa.Sign(rand.Reader, k, sum[:])
+			if err != nil {
+				return nil, err
+			}
+			// r and s are serialized as fixed size big-endian integers
+			sig := make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+			return sig, nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("signing algorithm %q does not match the private key type %T", alg, key)
+}
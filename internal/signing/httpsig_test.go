@@ -0,0 +1,239 @@
+//go:build unit
+// +build unit
+
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestSignatureBaseRFC9421 checks the signature base against
+// the RFC 9421 (appendix B.2.5) HMAC-SHA256 example.
+func TestSignatureBaseRFC9421(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/foo?param=Value&Pet=dog", nil)
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+
+	components := []string{"date", "@authority", "content-type"}
+	params := `("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`
+
+	base, err := SignatureBase(req, components, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `"date": Tue, 20 Apr 2021 02:07:55 GMT
+"@authority": example.com
+"content-type": application/json
+"@signature-params": ("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`
+	if base != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, base)
+	}
+
+	key, _ := base64.StdEncoding.DecodeString("uzvJfB4u3N0Jy4T7NZ75MDVcr8zSTInedJtkgcu46YW4XByzNJjxBdtjUkdJPBtbmHhIDi6pcl8jsasjlTMtDQ==")
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(base))
+	if got := base64.StdEncoding.EncodeToString(mac.Sum(nil)); got != "pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=" {
+		t.Errorf("unexpected signature: %s", got)
+	}
+}
+
+func TestHTTPSigSigner(t *testing.T) {
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tests := []struct {
+		alg    string
+		key    crypto.Signer
+		verify func(base, sig []byte) bool
+	}{
+		{
+			alg: AlgHMACSHA256,
+			verify: func(base, sig []byte) bool {
+				mac := hmac.New(sha256.New, []byte("shared"))
+				mac.Write(base)
+				return hmac.Equal(mac.Sum(nil), sig)
+			},
+		},
+		{
+			alg: AlgEd25519, key: edKey,
+			verify: func(base, sig []byte) bool {
+				return ed25519.Verify(edPub, base, sig)
+			},
+		},
+		{
+			alg: AlgRSAPSSSHA512, key: rsaKey,
+			verify: func(base, sig []byte) bool {
+				sum := sha512.Sum512(base)
+				return rsa.VerifyPSS(&rsaKey.PublicKey, crypto.SHA512, sum[:], sig, &rsa.PSSOptions{SaltLength: 64}) == nil
+			},
+		},
+		{
+			alg: AlgRSAv15SHA256, key: rsaKey,
+			verify: func(base, sig []byte) bool {
+				sum := sha256.Sum256(base)
+				return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, sum[:], sig) == nil
+			},
+		},
+		{
+			alg: AlgECDSAP256SHA256, key: ecKey,
+			verify: func(base, sig []byte) bool {
+				sum := sha256.Sum256(base)
+				r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+				return len(sig) == 64 && ecdsa.Verify(&ecKey.PublicKey, sum[:], r, s)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.alg, func(t *testing.T) {
+			srv := httptest.NewServer(verifyingHandler(t, tc.alg, tc.verify))
+			defer srv.Close()
+
+			cfg := Config{
+				SchemeLabel:           SchemeHTTPSig,
+				HTTPSigKeyIDLabel:     "test-key",
+				HTTPSigAlgorithmLabel: tc.alg,
+				HMACSecretLabel:       "shared",
+			}
+			if tc.key != nil {
+				der, err := x509.MarshalPKCS8PrivateKey(tc.key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cfg[HTTPSigPrivateKeyLabel] = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+			}
+
+			s, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, body := range []string{`{"hello": "world"}`, ""} {
+				req, _ := http.NewRequest(http.MethodPost, srv.URL+"/items?page=2", strings.NewReader(body))
+				if err := s.Sign(req, []byte(body)); err != nil {
+					t.Fatal(err)
+				}
+
+				res, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				msg, _ := io.ReadAll(res.Body)
+				res.Body.Close()
+
+				if res.StatusCode != http.StatusOK {
+					t.Errorf("signature rejected (body %q): %s", body, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPSigSignerErrors(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edKey)
+
+	_, err := New(Config{
+		SchemeLabel:            SchemeHTTPSig,
+		HTTPSigKeyIDLabel:      "test-key",
+		HTTPSigAlgorithmLabel:  AlgRSAPSSSHA512,
+		HTTPSigPrivateKeyLabel: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+	if err == nil {
+		t.Errorf("expected algorithm and key mismatch error")
+	}
+
+	s, err := New(Config{
+		SchemeLabel:            SchemeHTTPSig,
+		HTTPSigKeyIDLabel:      "test-key",
+		HMACSecretLabel:        "shared",
+		HTTPSigComponentsLabel: "@method, x-missing",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if err := s.Sign(req, nil); err == nil {
+		t.Errorf("expected missing covered header error")
+	}
+}
+
+var (
+	sigInputRE = regexp.MustCompile(`^sig1=(\((?:"[^"]+" ?)*\);created=\d+;keyid="test-key";alg="([^"]+)")$`)
+	sigRE      = regexp.MustCompile(`^sig1=:([A-Za-z0-9+/=]+):$`)
+)
+
+// verifyingHandler is a stand-in service verifying the request signature.
+func verifyingHandler(t *testing.T, alg string, verify func(base, sig []byte) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fail := func(format string, args ...any) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, format, args...)
+		}
+
+		input := sigInputRE.FindStringSubmatch(r.Header.Get("Signature-Input"))
+		if input == nil || input[2] != alg {
+			fail("invalid Signature-Input: %s", r.Header.Get("Signature-Input"))
+			return
+		}
+		sigval := sigRE.FindStringSubmatch(r.Header.Get("Signature"))
+		if sigval == nil {
+			fail("invalid Signature: %s", r.Header.Get("Signature"))
+			return
+		}
+		sig, _ := base64.StdEncoding.DecodeString(sigval[1])
+
+		params := input[1]
+		components := []string{}
+		for _, el := range strings.Fields(params[1:strings.Index(params, ")")]) {
+			components = append(components, strings.Trim(el, `"`))
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > 0 {
+			sum := sha256.Sum256(body)
+			want := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+			if r.Header.Get("Content-Digest") != want || !strings.Contains(params, `"content-digest"`) {
+				fail("invalid Content-Digest: %s", r.Header.Get("Content-Digest"))
+				return
+			}
+		}
+
+		// the target URI as seen by the client
+		r.URL.Scheme, r.URL.Host = "http", r.Host
+
+		base, err := SignatureBase(r, components, params)
+		if err != nil {
+			fail("%s", err.Error())
+			return
+		}
+		if !verify([]byte(base), sig) {
+			fail("signature mismatch for base:\n%s", base)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
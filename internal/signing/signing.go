@@ -0,0 +1,90 @@
+package signing
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SchemeLabel is the endpoint secret key selecting the signing scheme.
+const SchemeLabel = "signing-scheme"
+
+// Signer signs an outgoing request; body is the request payload
+// (nil if none). Signers only add or replace request headers.
+type Signer interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// Config holds the endpoint secret values.
+type Config map[string]string
+
+// Get returns the value of the key, or the default value if not set.
+func (c Config) Get(key, def string) string {
+	if v := strings.TrimSpace(c[key]); len(v) > 0 {
+		return v
+	}
+	return def
+}
+
+// Required returns the value of the key, or an error if not set.
+func (c Config) Required(key string) (string, error) {
+	v := strings.TrimSpace(c[key])
+	if len(v) == 0 {
+		return "", fmt.Errorf("missed required attribute for signing scheme: %s", key)
+	}
+	return v, nil
+}
+
+// Factory creates a Signer from the endpoint secret values.
+type Factory func(cfg Config) (Signer, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{}
+)
+
+// Register makes a signing scheme available; it panics
+// if the scheme is registered twice.
+func Register(scheme string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := factories[scheme]; ok {
+		panic(fmt.Sprintf("signing scheme %q already registered", scheme))
+	}
+	factories[scheme] = f
+}
+
+// Schemes returns the registered scheme names, sorted.
+func Schemes() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	all := make([]string, 0, len(factories))
+	for k := range factories {
+		all = append(all, k)
+	}
+	sort.Strings(all)
+	return all
+}
+
+// New creates the Signer for the scheme declared in the configuration;
+// it returns nil if no scheme is declared.
+func New(cfg Config) (Signer, error) {
+	scheme := cfg.Get(SchemeLabel, "")
+	if len(scheme) == 0 {
+		return nil, nil
+	}
+
+	mu.RLock()
+	f, ok := factories[scheme]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signing scheme %q (available: %s)",
+			scheme, strings.Join(Schemes(), ", "))
+	}
+
+	return f(cfg)
+}
@@ -0,0 +1,60 @@
+//go:build unit
+// +build unit
+
+package signing
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	got, err := New(Config{"server-url": "https://example.com"})
+	if err != nil || got != nil {
+		t.Fatalf("expected no signer, got %v (%v)", got, err)
+	}
+
+	_, err = New(Config{SchemeLabel: "unknown"})
+	if err == nil || !strings.Contains(err.Error(), SchemeHMAC) {
+		t.Errorf("expected unknown scheme error listing the schemes, got %v", err)
+	}
+
+	_, err = New(Config{SchemeLabel: SchemeHMAC})
+	if err == nil || !strings.Contains(err.Error(), HMACSecretLabel) {
+		t.Errorf("expected missing secret error, got %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("test-static", func(cfg Config) (Signer, error) {
+		return signerFunc(func(req *http.Request, _ []byte) error {
+			req.Header.Set("X-Static", cfg.Get("static-value", "none"))
+			return nil
+		}), nil
+	})
+
+	s, err := New(Config{SchemeLabel: "test-static", "static-value": "ok"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	s.Sign(req, nil)
+	if got := req.Header.Get("X-Static"); got != "ok" {
+		t.Errorf("expected registered signer to be used, got %q", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic registering a scheme twice")
+		}
+	}()
+	Register("test-static", nil)
+}
+
+type signerFunc func(req *http.Request, body []byte) error
+
+func (f signerFunc) Sign(req *http.Request, body []byte) error {
+	return f(req, body)
+}
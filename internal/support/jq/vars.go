@@ -0,0 +1,63 @@
+package jq
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/itchyny/gojq"
+)
+
+// Iteration variables bound to the iterated expressions.
+const (
+	VarItem  = "item"
+	VarIndex = "index"
+)
+
+// Var is a variable bound while evaluating a query.
+type Var struct {
+	// Name of the variable, without the leading $.
+	Name  string
+	Value any
+}
+
+// Bind returns the query, and the data to evaluate it on, so that the
+// original query sees the original data as input with the variables
+// bound by name (i.e. $item); imports and includes are preserved.
+func Bind(q string, data any, vars ...Var) (string, any, error) {
+	if len(vars) == 0 {
+		return q, data, nil
+	}
+
+	query, err := gojq.Parse(q)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid jq query %q: %w", q, err)
+	}
+
+	var sb strings.Builder
+	for _, el := range query.Imports {
+		sb.WriteString(el.String())
+	}
+	query.Imports = nil
+
+	names := make([]string, 0, len(vars)+1)
+	values := make([]any, 0, len(vars)+1)
+
+	names = append(names, "$__data")
+	values = append(values, data)
+	for _, el := range vars {
+		names = append(names, "$"+el.Name)
+		values = append(values, el.Value)
+	}
+
+	fmt.Fprintf(&sb, ". as [%s] | $__data | (%s)", strings.Join(names, ", "), query.String())
+
+	return sb.String(), values, nil
+}
+
+// IterationVars returns the $item and $index variables.
+func IterationVars(item any, index int) []Var {
+	return []Var{
+		{Name: VarItem, Value: item},
+		{Name: VarIndex, Value: index},
+	}
+}
@@ -0,0 +1,68 @@
+//go:build unit
+// +build unit
+
+package jq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krateoplatformops/plumbing/jqutil"
+)
+
+func TestBind(t *testing.T) {
+	dict := map[string]any{
+		"extras": map[string]any{"prefix": "ns-"},
+		"names":  []any{"demo", "krateo"},
+	}
+
+	tests := []struct {
+		name string
+		q    string
+		vars []Var
+		want string
+	}{
+		{
+			name: "no vars",
+			q:    `.names | length`,
+			want: "2",
+		},
+		{
+			name: "item and index",
+			q:    `.extras.prefix + $item + "-" + ($index|tostring)`,
+			vars: IterationVars("krateo", 1),
+			want: "ns-krateo-1",
+		},
+		{
+			name: "function definitions",
+			q:    `def up: ascii_upcase; $item | up`,
+			vars: IterationVars("demo", 0),
+			want: "DEMO",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q, data, err := Bind(tc.q, dict, tc.vars...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := jqutil.Eval(context.TODO(), jqutil.EvalOptions{
+				Query: q, Data: data, Unquote: true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBindInvalid(t *testing.T) {
+	if _, _, err := Bind(`.names |`, nil, IterationVars("demo", 0)...); err == nil {
+		t.Fatal("expected error")
+	}
+}
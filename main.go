@@ -20,6 +20,8 @@ import (
 	_ "github.com/krateoplatformops/snowplow/docs"
 	"github.com/krateoplatformops/snowplow/internal/handlers"
 	"github.com/krateoplatformops/snowplow/internal/handlers/dispatchers"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/api"
+	"github.com/krateoplatformops/snowplow/internal/resolvers/restactions/fixtures"
 	jqsupport "github.com/krateoplatformops/snowplow/internal/support/jq"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -46,6 +48,22 @@ func main() {
 	signKey := flag.String("jwt-sign-key", env.String("JWT_SIGN_KEY", ""), "secret key used to sign JWT tokens")
 	jqModPath := flag.String("jq-modules-path", env.String(jqsupport.EnvModulesPath, ""),
 		"loads JQ custom modules from the filesystem")
+	apiMaxConcurrency := flag.Int("api-max-concurrency", env.Int(api.EnvMaxConcurrency, 8),
+		"maximum number of RESTAction API calls resolved concurrently")
+	fixturesDir := flag.String("fixtures-dir", env.String(fixtures.EnvDir, ""),
+		"directory of the RESTAction recorded fixtures")
+	fixturesMode := flag.String("fixtures-mode", env.String(fixtures.EnvMode, ""),
+		"default RESTAction fixtures mode (record, replay, off)")
+	fixturesOverride := flag.Bool("fixtures-override", env.Bool(fixtures.EnvOverride, false),
+		"allow the fixtures query parameter to override the fixtures mode (development only)")
+	batchMaxConcurrency := flag.Int("batch-max-concurrency", env.Int(handlers.EnvBatchMaxConcurrency, 8),
+		"maximum number of batch items resolved concurrently")
+	batchMaxItems := flag.Int("batch-max-items", env.Int(handlers.EnvBatchMaxItems, 100),
+		"maximum number of items of a batch request")
+	watchHeartbeat := flag.Duration("watch-heartbeat", env.Duration(handlers.EnvWatchHeartbeat, 30*time.Second),
+		"interval between the heartbeats of idle watch streams")
+	widgetsStreamDebounce := flag.Duration("widgets-stream-debounce", env.Duration(handlers.EnvWidgetsStreamDebounce, time.Second),
+		"how long a widget stream waits for further changes before resolving the widget again")
 
 	flag.Usage = func() {
 		fmt.Fprintln(flag.CommandLine.Output(), "Flags:")
@@ -58,6 +76,14 @@ func main() {
 	os.Setenv("TRACE", strconv.FormatBool(*blizzardOn))
 	os.Setenv("AUTHN_NAMESPACE", *authnNS)
 	os.Setenv(jqsupport.EnvModulesPath, *jqModPath)
+	os.Setenv(api.EnvMaxConcurrency, strconv.Itoa(*apiMaxConcurrency))
+	os.Setenv(fixtures.EnvDir, *fixturesDir)
+	os.Setenv(fixtures.EnvMode, *fixturesMode)
+	os.Setenv(fixtures.EnvOverride, strconv.FormatBool(*fixturesOverride))
+	os.Setenv(handlers.EnvBatchMaxConcurrency, strconv.Itoa(*batchMaxConcurrency))
+	os.Setenv(handlers.EnvBatchMaxItems, strconv.Itoa(*batchMaxItems))
+	os.Setenv(handlers.EnvWatchHeartbeat, watchHeartbeat.String())
+	os.Setenv(handlers.EnvWidgetsStreamDebounce, widgetsStreamDebounce.String())
 
 	logLevel := slog.LevelInfo
 	if *debugOn {
@@ -109,7 +135,15 @@ func main() {
 	mux.Handle("PATCH /call", chain.Append(use.UserConfig(*signKey, *authnNS)).Then(handlers.Call()))
 	mux.Handle("DELETE /call", chain.Append(use.UserConfig(*signKey, *authnNS)).Then(handlers.Call()))
 
+	mux.Handle("POST /batch", chain.Append(use.UserConfig(*signKey, *authnNS)).
+		Then(handlers.Batch(handlers.Dispatcher(dispatchers.All())(handlers.Call()))))
+
+	mux.Handle("GET /watch", chain.Append(use.UserConfig(*signKey, *authnNS)).Then(handlers.Watch()))
+	mux.Handle("GET /widgets/stream", chain.Append(use.UserConfig(*signKey, *authnNS)).
+		Then(handlers.WidgetsStream(handlers.Dispatcher(dispatchers.All())(handlers.Call()))))
+
 	mux.Handle("POST /jq", chain.Append(use.UserConfig(*signKey, *authnNS)).Then(handlers.JQ()))
+	mux.Handle("POST /restactions/validate", chain.Append(use.UserConfig(*signKey, *authnNS)).Then(handlers.Validate()))
 
 	ctx, stop := signal.NotifyContext(context.Background(), []os.Signal{
 		os.Interrupt,
@@ -130,6 +164,7 @@ func main() {
 				"Accept",
 				"Authorization",
 				"Content-Type",
+				"Last-Event-ID",
 				"X-Auth-Code",
 				"X-Krateo-TraceId",
 			},